@@ -3,6 +3,7 @@ package sqlj
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -24,73 +25,117 @@ func buildDeleteSQL(options deleteParams) string {
 }
 
 type insertParams struct {
-	From      string
-	Fields    []field
-	Returning []string
+	From                string
+	Fields              []field
+	Returning           []string
+	OnConflictDoNothing bool
 }
 
 func buildInsertSQL(options insertParams) string {
-	names := make([]string, len(options.Fields))
-	placeholders := make([]string, len(options.Fields))
+	var sql string
+
+	// Every column may be omitted, for example by omitempty tag options.
+	if len(options.Fields) == 0 {
+		sql = "INSERT INTO " + options.From + " DEFAULT VALUES"
+	} else {
+		names := make([]string, len(options.Fields))
+		placeholders := make([]string, len(options.Fields))
+
+		n := 0
+		for idx, f := range options.Fields {
+			names[idx] = f.GetName()
+			placeholders[idx] = f.GetPlaceholder(n + 1)
+
+			if !f.IsLiteral() {
+				n++
+			}
+		}
 
-	n := 0
-	for idx, f := range options.Fields {
-		names[idx] = f.GetName()
-		placeholders[idx] = f.GetPlaceholder(n + 1)
+		sql = strings.Join(
+			[]string{
+				"INSERT INTO ",
+				options.From,
+				" (",
+				strings.Join(names, ", "),
+				") VALUES (",
+				strings.Join(placeholders, ", "),
+				")",
+			},
+			"",
+		)
+	}
 
-		if !f.IsLiteral() {
-			n++
-		}
+	// SQLite doesn't accept a conflict clause after DEFAULT VALUES.
+	if options.OnConflictDoNothing && len(options.Fields) > 0 {
+		sql += " ON CONFLICT DO NOTHING"
 	}
 
-	return strings.Join(
-		[]string{
-			"INSERT INTO ",
-			options.From,
-			" (",
-			strings.Join(names, ", "),
-			") VALUES (",
-			strings.Join(placeholders, ", "),
-			") RETURNING ",
-			strings.Join(options.Returning, ", "),
-		},
-		"",
-	)
+	return strings.Join([]string{sql, " RETURNING ", strings.Join(options.Returning, ", ")}, "")
 }
 
 type updateParams struct {
 	From      string
 	Fields    []field
+	IDColumn  string
+	Where     []WhereClause // Conditions in addition to the ID, e.g. for optimistic locking
 	Returning []string
 }
 
 func buildUpdateSQL(options updateParams) string {
 	setExpressions := make([]string, len(options.Fields))
+
+	n := 0
 	for idx, f := range options.Fields {
-		setExpressions[idx] = fmt.Sprintf("%s = %s", f.GetName(), f.GetPlaceholder(idx+1))
+		setExpressions[idx] = fmt.Sprintf("%s = %s", f.GetName(), f.GetPlaceholder(n+1))
+
+		if !f.IsLiteral() {
+			n++
+		}
+	}
+
+	idColumn := options.IDColumn
+	if idColumn == "" {
+		idColumn = "id"
 	}
 
-	return strings.Join(
+	sql := strings.Join(
 		[]string{
 			"UPDATE ",
 			options.From,
 			" SET ",
 			strings.Join(setExpressions, ", "),
-			fmt.Sprintf(" WHERE id = $%d ", len(options.Fields)+1),
-			"RETURNING ",
-			strings.Join(options.Returning, ", "),
+			fmt.Sprintf(" WHERE %s = $%d", idColumn, n+1),
 		},
 		"",
 	)
+
+	if len(options.Where) > 0 {
+		whereSQL, _ := replacePlaceholder(joinWhereClauses(options.Where), uint(n+1))
+
+		if len(options.Where) > 1 {
+			whereSQL = parens(whereSQL)
+		}
+
+		sql = strings.Join([]string{sql, " AND ", whereSQL}, "")
+	}
+
+	if len(options.Returning) > 0 {
+		sql = strings.Join([]string{sql, " RETURNING ", strings.Join(options.Returning, ", ")}, "")
+	}
+
+	return sql
 }
 
 type selectParams struct {
-	From    string
-	Where   []WhereClause
-	OrderBy []orderBy
-	Offset  bool
-	Limit   bool
-	Columns []string
+	From       string
+	Where      []WhereClause
+	OrderBy    []orderBy
+	Offset     bool
+	Limit      bool
+	Columns    []string
+	Distinct   bool
+	DistinctOn []string
+	Dialect    Dialect
 }
 
 type orderBy struct {
@@ -99,7 +144,15 @@ type orderBy struct {
 }
 
 func buildSelectQuery(options selectParams) string {
-	sql := strings.Join([]string{"SELECT ", strings.Join(options.Columns, ", "), " FROM ", options.From}, "")
+	selectSQL := "SELECT "
+
+	if len(options.DistinctOn) > 0 {
+		selectSQL = strings.Join([]string{"SELECT DISTINCT ON ", parens(strings.Join(options.DistinctOn, ", ")), " "}, "")
+	} else if options.Distinct {
+		selectSQL = "SELECT DISTINCT "
+	}
+
+	sql := strings.Join([]string{selectSQL, strings.Join(options.Columns, ", "), " FROM ", options.From}, "")
 
 	var placeholderOffset uint = 0
 	if len(options.Where) > 0 {
@@ -124,6 +177,11 @@ func buildSelectQuery(options selectParams) string {
 		placeholderOffset++
 	}
 
+	// SQLite only accepts an OFFSET following a LIMIT, a negative limit means no limit.
+	if options.Offset && !options.Limit && options.Dialect == SQLiteDialect {
+		sql = strings.Join([]string{sql, " LIMIT -1"}, "")
+	}
+
 	if options.Offset {
 		sql = strings.Join([]string{sql, " OFFSET ", fmt.Sprintf("$%d", placeholderOffset+1)}, "")
 		placeholderOffset++
@@ -189,14 +247,49 @@ func replacePlaceholder(expr string, offset uint) (string, uint) {
 
 func indexMatches(expr string) []uint {
 	matches := []uint{}
+
+	for _, p := range scanPlaceholders(expr) {
+		if expr[p.Start] == '?' {
+			matches = append(matches, uint(p.Start))
+		}
+	}
+
+	return matches
+}
+
+// A placeholder found in an SQL expression.
+// Index is the 1-based position of the value it refers to.
+type placeholderMatch struct {
+	Start int
+	End   int
+	Index int
+}
+
+// Finds the ? and $n placeholders in expr, ignoring any within quoted strings.
+// A ? refers to the value following the previous ? placeholder.
+func scanPlaceholders(expr string) []placeholderMatch {
+	matches := []placeholderMatch{}
 	inQuote := false
 	escaping := false
+	questionMarks := 0
 
 	for i := 0; i < len(expr); i++ {
 		switch expr[i] {
 		case '?':
 			if !inQuote {
-				matches = append(matches, uint(i))
+				questionMarks++
+				matches = append(matches, placeholderMatch{Start: i, End: i + 1, Index: questionMarks})
+			}
+		case '$':
+			end := i + 1
+			for end < len(expr) && expr[end] >= '0' && expr[end] <= '9' {
+				end++
+			}
+
+			if !inQuote && end > i+1 {
+				index, _ := strconv.Atoi(expr[i+1 : end])
+				matches = append(matches, placeholderMatch{Start: i, End: end, Index: index})
+				i = end - 1
 			}
 		case '\'':
 			if !escaping {
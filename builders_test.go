@@ -118,3 +118,108 @@ func TestReplacePlaceholder(t *testing.T) {
 		t.Fatalf("Expected 1 replacement, got: %d - %s", replacements, result)
 	}
 }
+
+func TestBuildSeekExpr(t *testing.T) {
+	expr, values := buildSeekExpr([]orderBy{{"id", "ASC"}}, []any{int64(1)})
+
+	if expr.String() != "id > ?" || len(values) != 1 {
+		t.Fatalf("Single column seek failed: %s\n", expr.String())
+	}
+
+	expr, values = buildSeekExpr([]orderBy{{"name", "DESC"}, {"id", "desc"}}, []any{"Joe", int64(2)})
+
+	if expr.String() != "(name, id) < (?, ?)" || len(values) != 2 {
+		t.Fatalf("Uniform direction seek failed: %s\n", expr.String())
+	}
+
+	expr, values = buildSeekExpr([]orderBy{{"name", "DESC"}, {"age", "ASC"}, {"id", "ASC"}}, []any{"Joe", int64(30), int64(2)})
+
+	if expr.String() != "(name < ? OR (name = ? AND age > ?) OR (name = ? AND age = ? AND id > ?))" {
+		t.Fatalf("Mixed direction seek failed: %s\n", expr.String())
+	}
+
+	if len(values) != 6 || values[1] != "Joe" || values[4] != int64(30) {
+		t.Fatalf("Mixed direction seek values are incorrect: %v\n", values)
+	}
+}
+
+func TestBuildSelectQuery(t *testing.T) {
+	result := buildSelectQuery(selectParams{
+		Columns: []string{"id", "name"},
+		From:    "user",
+		Where:   []WhereClause{{"AND", SimpleExpr{"name = ?"}}},
+		Limit:   true,
+		Offset:  true,
+	})
+
+	if result != "SELECT id, name FROM user WHERE name = $1 LIMIT $2 OFFSET $3" {
+		t.Fatalf("Limit and offset select failed: %s\n", result)
+	}
+
+	result = buildSelectQuery(selectParams{
+		Columns:  []string{"name"},
+		From:     "user",
+		Distinct: true,
+	})
+
+	if result != "SELECT DISTINCT name FROM user" {
+		t.Fatalf("Distinct select failed: %s\n", result)
+	}
+
+	result = buildSelectQuery(selectParams{
+		Columns:    []string{"id", "name"},
+		From:       "user",
+		Distinct:   true,
+		DistinctOn: []string{"name"},
+		OrderBy:    []orderBy{{"name", "ASC"}, {"id", "DESC"}},
+	})
+
+	if result != "SELECT DISTINCT ON (name) id, name FROM user ORDER BY name ASC, id DESC" {
+		t.Fatalf("Distinct on select failed: %s\n", result)
+	}
+
+	result = buildSelectQuery(selectParams{
+		Columns: []string{"id"},
+		From:    "user",
+		Offset:  true,
+		Dialect: SQLiteDialect,
+	})
+
+	if result != "SELECT id FROM user LIMIT -1 OFFSET $1" {
+		t.Fatalf("SQLite offset without limit failed: %s\n", result)
+	}
+
+	result = buildSelectQuery(selectParams{
+		Columns: []string{"id"},
+		From:    "user",
+		Offset:  true,
+		Dialect: PostgresDialect,
+	})
+
+	if result != "SELECT id FROM user OFFSET $1" {
+		t.Fatalf("Postgres offset without limit failed: %s\n", result)
+	}
+}
+
+func TestBuildInsertSQL(t *testing.T) {
+	sql := buildInsertSQL(insertParams{
+		From:                "user",
+		Fields:              []field{basicField{Name: "email", Value: "joe@example.com"}},
+		Returning:           []string{"id", "email"},
+		OnConflictDoNothing: true,
+	})
+
+	if sql != "INSERT INTO user (email) VALUES ($1) ON CONFLICT DO NOTHING RETURNING id, email" {
+		t.Fatalf("Unexpected insert SQL: %s\n", sql)
+	}
+
+	sql = buildInsertSQL(insertParams{
+		From:                "user",
+		Returning:           []string{"id"},
+		OnConflictDoNothing: true,
+	})
+
+	if sql != "INSERT INTO user DEFAULT VALUES RETURNING id" {
+		t.Fatalf("Unexpected insert SQL without fields: %s\n", sql)
+	}
+}
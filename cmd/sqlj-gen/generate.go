@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A struct with db tagged fields found in the source.
+type model struct {
+	Name   string
+	Fields []modelField
+}
+
+type modelField struct {
+	Name   string
+	Column string
+	JSON   bool // Stored as JSON with sqlj.JSON
+}
+
+// Parses the Go files in dir and returns the package name and the structs with db tagged fields.
+// If types is not empty only the named structs are returned.
+func findModels(dir string, types []string) (string, []model, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := token.NewFileSet()
+
+	var pkgName string
+	var models []model
+
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if pkgName == "" {
+			pkgName = file.Name.Name
+		} else if pkgName != file.Name.Name {
+			return "", nil, fmt.Errorf("Found packages %s and %s in %s", pkgName, file.Name.Name, dir)
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+
+			structType, ok := spec.Type.(*ast.StructType)
+			if !ok || spec.TypeParams != nil {
+				return true
+			}
+
+			if len(types) > 0 && !slices.Contains(types, spec.Name.Name) {
+				return true
+			}
+
+			if m := structModel(spec.Name.Name, structType); len(m.Fields) > 0 {
+				models = append(models, m)
+			}
+
+			return true
+		})
+	}
+
+	if pkgName == "" {
+		return "", nil, fmt.Errorf("No Go files found in %s", dir)
+	}
+
+	for _, t := range types {
+		if !slices.ContainsFunc(models, func(m model) bool { return m.Name == t }) {
+			return "", nil, fmt.Errorf("No struct named %s with db tagged fields found", t)
+		}
+	}
+
+	return pkgName, models, nil
+}
+
+// Reads the db tagged fields of a struct the same way sqlj does at runtime.
+func structModel(name string, structType *ast.StructType) model {
+	m := model{Name: name}
+
+	for _, f := range structType.Fields.List {
+		if f.Tag == nil || len(f.Names) == 0 {
+			continue
+		}
+
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+
+		options := strings.Split(reflect.StructTag(tag).Get("db"), ",")
+
+		column := strings.TrimSpace(options[0])
+		if column == "" || column == "-" {
+			continue
+		}
+
+		json := false
+
+		for _, option := range options[1:] {
+			option = strings.TrimSpace(option)
+
+			// The default option takes the rest of the tag.
+			if strings.HasPrefix(option, "default=") {
+				break
+			}
+
+			if option == "json" {
+				json = true
+			}
+		}
+
+		for _, fieldName := range f.Names {
+			if !fieldName.IsExported() {
+				continue
+			}
+
+			m.Fields = append(m.Fields, modelField{Name: fieldName.Name, Column: column, JSON: json})
+		}
+	}
+
+	return m
+}
+
+// Generates the sqlj.GeneratedModel methods for the models.
+func generate(pkgName string, models []model) ([]byte, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by sqlj-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n", pkgName)
+
+	if slices.ContainsFunc(models, hasJSONField) {
+		fmt.Fprintf(&b, "\nimport \"github.com/JoeAxon/sqlj\"\n")
+	}
+
+	for _, m := range models {
+		columns := make([]string, len(m.Fields))
+		targets := make([]string, len(m.Fields))
+		values := make([]string, len(m.Fields))
+
+		for idx, f := range m.Fields {
+			columns[idx] = strconv.Quote(f.Column)
+			targets[idx] = "&r." + f.Name
+			values[idx] = "r." + f.Name
+
+			if f.JSON {
+				targets[idx] = "sqlj.JSON(&r." + f.Name + ")"
+				values[idx] = targets[idx]
+			}
+		}
+
+		fmt.Fprintf(&b, "\nfunc (r *%s) SqljColumns() []string {\n\treturn []string{%s}\n}\n", m.Name, strings.Join(columns, ", "))
+		fmt.Fprintf(&b, "\nfunc (r *%s) SqljScanTargets() []any {\n\treturn []any{%s}\n}\n", m.Name, strings.Join(targets, ", "))
+		fmt.Fprintf(&b, "\nfunc (r *%s) SqljValues() []any {\n\treturn []any{%s}\n}\n", m.Name, strings.Join(values, ", "))
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func hasJSONField(m model) bool {
+	return slices.ContainsFunc(m.Fields, func(f modelField) bool { return f.JSON })
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const source = `package models
+
+import "time"
+
+type User struct {
+	ID        uint      ` + "`db:\"id\"`" + `
+	Name      string    ` + "`db:\"name,readonly\"`" + `
+	Ignored   string    ` + "`db:\"-\"`" + `
+	NotInDB   string
+	CreatedAt time.Time ` + "`db:\"created_at\"`" + `
+	Settings  Settings  ` + "`db:\"settings,json\"`" + `
+}
+
+type Settings struct {
+	Theme string
+}
+`
+
+const expected = `// Code generated by sqlj-gen. DO NOT EDIT.
+
+package models
+
+import "github.com/JoeAxon/sqlj"
+
+func (r *User) SqljColumns() []string {
+	return []string{"id", "name", "created_at", "settings"}
+}
+
+func (r *User) SqljScanTargets() []any {
+	return []any{&r.ID, &r.Name, &r.CreatedAt, sqlj.JSON(&r.Settings)}
+}
+
+func (r *User) SqljValues() []any {
+	return []any{r.ID, r.Name, r.CreatedAt, sqlj.JSON(&r.Settings)}
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to write source: %s\n", err.Error())
+	}
+
+	if err := run(dir, "", ""); err != nil {
+		t.Fatalf("Failed to generate: %s\n", err.Error())
+	}
+
+	result, err := os.ReadFile(filepath.Join(dir, "models_sqlj.go"))
+
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %s\n", err.Error())
+	}
+
+	if string(result) != expected {
+		t.Fatalf("Unexpected generated code:\n%s\n", result)
+	}
+
+	// Running again should read the generated file without including it.
+	if err := run(dir, "User", ""); err != nil {
+		t.Fatalf("Failed to regenerate: %s\n", err.Error())
+	}
+
+	if err := run(dir, "Settings", ""); err == nil || !strings.Contains(err.Error(), "Settings") {
+		t.Fatalf("Expected an error for a struct without db tags, got: %v\n", err)
+	}
+}
@@ -0,0 +1,75 @@
+// sqlj-gen generates the methods sqlj uses in place of reflection for structs with db tags.
+//
+// It is intended to be used with go:generate:
+//
+//	//go:generate go run github.com/JoeAxon/sqlj/cmd/sqlj-gen -type User,Issue
+//
+// Without -type every struct with db tagged fields in the package is included.
+// The methods are written to <package>_sqlj.go unless -output is given.
+//
+// The schema command generates structs from the tables in a SQLite or PostgreSQL database:
+//
+//	sqlj-gen schema -driver postgres -dsn "$PG_DSN" -package models -output models.go
+//
+// Run sqlj-gen schema -h to see the naming and formatting options.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		if err := runSchema(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "sqlj-gen schema: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	typeNames := flag.String("type", "", "comma separated list of struct names, defaults to every struct with db tags")
+	output := flag.String("output", "", "output file name, defaults to <package>_sqlj.go")
+	dir := flag.String("dir", ".", "directory of the package to read")
+
+	flag.Parse()
+
+	if err := run(*dir, *typeNames, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "sqlj-gen: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(dir string, typeNames string, output string) error {
+	var types []string
+	if typeNames != "" {
+		types = strings.Split(typeNames, ",")
+	}
+
+	pkgName, models, err := findModels(dir, types)
+	if err != nil {
+		return err
+	}
+
+	if len(models) == 0 {
+		return fmt.Errorf("No structs with db tagged fields found in %s", dir)
+	}
+
+	src, err := generate(pkgName, models)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = pkgName + "_sqlj.go"
+	}
+
+	return os.WriteFile(filepath.Join(dir, output), src, 0o644)
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JoeAxon/sqlj"
+)
+
+// The options for generating structs from a database schema.
+type schemaOptions struct {
+	Package     string
+	Tables      []string
+	Schema      string          // The Postgres schema to read, defaults to public
+	Nullable    string          // "pointer" or "sql", how nullable columns are represented
+	Singularize bool            // Whether table names are made singular for the struct names
+	Initialisms map[string]bool // Words written in upper case in names, such as ID
+	JSONTags    bool            // Whether to add json tags alongside the db tags
+}
+
+var defaultInitialisms = []string{"API", "HTML", "HTTP", "ID", "IP", "JSON", "SQL", "URI", "URL", "UUID", "XML"}
+
+type schemaTable struct {
+	Name    string
+	Columns []schemaColumn
+}
+
+type schemaColumn struct {
+	Name       string
+	Type       string // The Go type ignoring nullability
+	Nullable   bool
+	PrimaryKey bool
+}
+
+// Parses the flags for the schema command, introspects the database and writes the structs.
+func runSchema(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+
+	driver := flags.String("driver", "", "database/sql driver name: sqlite3 or postgres")
+	dsn := flags.String("dsn", "", "data source name used to connect to the database")
+	pkg := flags.String("package", "models", "package name of the generated file")
+	tables := flags.String("tables", "", "comma separated list of tables, defaults to every table")
+	schema := flags.String("schema", "public", "Postgres schema to read")
+	nullable := flags.String("nullable", "pointer", "how nullable columns are represented: pointer or sql")
+	singularize := flags.Bool("singular", true, "make table names singular for struct names")
+	initialisms := flags.String("initialisms", "", "comma separated list of extra words to write in upper case")
+	jsonTags := flags.Bool("json", false, "add json tags alongside the db tags")
+	output := flags.String("output", "", "output file, defaults to stdout")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *driver == "" || *dsn == "" {
+		return fmt.Errorf("-driver and -dsn are required")
+	}
+
+	if *nullable != "pointer" && *nullable != "sql" {
+		return fmt.Errorf("-nullable must be pointer or sql, got: %s", *nullable)
+	}
+
+	options := schemaOptions{
+		Package:     *pkg,
+		Schema:      *schema,
+		Nullable:    *nullable,
+		Singularize: *singularize,
+		Initialisms: map[string]bool{},
+		JSONTags:    *jsonTags,
+	}
+
+	if *tables != "" {
+		options.Tables = strings.Split(*tables, ",")
+	}
+
+	for _, word := range defaultInitialisms {
+		options.Initialisms[word] = true
+	}
+
+	if *initialisms != "" {
+		for _, word := range strings.Split(*initialisms, ",") {
+			options.Initialisms[strings.ToUpper(strings.TrimSpace(word))] = true
+		}
+	}
+
+	db, err := sqlj.Open(*driver, *dsn)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	schemaTables, err := introspect(db, options)
+	if err != nil {
+		return err
+	}
+
+	src, err := generateStructs(schemaTables, options)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(src)
+
+		return err
+	}
+
+	return os.WriteFile(*output, src, 0o644)
+}
+
+// Reads the tables and columns from the database.
+func introspect(db *sqlj.DB, options schemaOptions) ([]schemaTable, error) {
+	switch db.Dialect {
+	case sqlj.SQLiteDialect:
+		return introspectSQLite(db, options)
+	case sqlj.PostgresDialect:
+		return introspectPostgres(db, options)
+	}
+
+	return nil, fmt.Errorf("Unsupported database, only SQLite and PostgreSQL can be introspected")
+}
+
+type tableName struct {
+	Name string `db:"name"`
+}
+
+type sqliteColumn struct {
+	Name    string `db:"name"`
+	Type    string `db:"type"`
+	NotNull bool   `db:"notnull"`
+	PK      int    `db:"pk"`
+}
+
+func introspectSQLite(db *sqlj.DB, options schemaOptions) ([]schemaTable, error) {
+	names, err := tableNames(db, options, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]schemaTable, len(names))
+
+	for idx, name := range names {
+		var columns []sqliteColumn
+
+		if err := db.SelectAll(`SELECT name, type, "notnull", pk FROM pragma_table_info($1) ORDER BY cid`, &columns, name); err != nil {
+			return nil, err
+		}
+
+		tables[idx] = schemaTable{Name: name}
+
+		for _, c := range columns {
+			tables[idx].Columns = append(tables[idx].Columns, schemaColumn{
+				Name: c.Name,
+				Type: sqliteGoType(c.Type),
+				// An INTEGER PRIMARY KEY is an alias for the rowid so can never be null.
+				Nullable:   !c.NotNull && c.PK == 0,
+				PrimaryKey: c.PK > 0,
+			})
+		}
+	}
+
+	return tables, nil
+}
+
+type postgresColumn struct {
+	Name       string `db:"column_name"`
+	Type       string `db:"data_type"`
+	IsNullable string `db:"is_nullable"`
+	PrimaryKey bool   `db:"primary_key"`
+}
+
+func introspectPostgres(db *sqlj.DB, options schemaOptions) ([]schemaTable, error) {
+	names, err := tableNames(db, options,
+		"SELECT table_name AS name FROM information_schema.tables WHERE table_schema = $1 AND table_type = 'BASE TABLE' ORDER BY table_name",
+		options.Schema,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]schemaTable, len(names))
+
+	for idx, name := range names {
+		var columns []postgresColumn
+
+		err := db.SelectAll(`
+			SELECT c.column_name, c.data_type, c.is_nullable, EXISTS (
+				SELECT 1 FROM information_schema.table_constraints tc
+				JOIN information_schema.key_column_usage kcu
+					ON kcu.constraint_name = tc.constraint_name
+					AND kcu.table_schema = tc.table_schema
+					AND kcu.table_name = tc.table_name
+				WHERE tc.constraint_type = 'PRIMARY KEY'
+					AND tc.table_schema = c.table_schema
+					AND tc.table_name = c.table_name
+					AND kcu.column_name = c.column_name
+			) AS primary_key
+			FROM information_schema.columns c
+			WHERE c.table_schema = $1 AND c.table_name = $2
+			ORDER BY c.ordinal_position`, &columns, options.Schema, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tables[idx] = schemaTable{Name: name}
+
+		for _, c := range columns {
+			tables[idx].Columns = append(tables[idx].Columns, schemaColumn{
+				Name:       c.Name,
+				Type:       postgresGoType(c.Type),
+				Nullable:   c.IsNullable == "YES",
+				PrimaryKey: c.PrimaryKey,
+			})
+		}
+	}
+
+	return tables, nil
+}
+
+// Lists the tables using the query, limited to the tables in the options if any are given.
+func tableNames(db *sqlj.DB, options schemaOptions, query string, values ...any) ([]string, error) {
+	var rows []tableName
+
+	if err := db.SelectAll(query, &rows, values...); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(rows))
+
+	for _, row := range rows {
+		if len(options.Tables) == 0 || slices.Contains(options.Tables, row.Name) {
+			names = append(names, row.Name)
+		}
+	}
+
+	for _, table := range options.Tables {
+		if !slices.Contains(names, table) {
+			return nil, fmt.Errorf("Table %s not found", table)
+		}
+	}
+
+	return names, nil
+}
+
+// Maps a declared SQLite column type to a Go type following SQLite's type affinity rules.
+func sqliteGoType(declared string) string {
+	t := strings.ToUpper(declared)
+
+	switch {
+	case strings.Contains(t, "BOOL"):
+		return "bool"
+	case strings.Contains(t, "INT"):
+		return "int64"
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return "string"
+	case t == "", strings.Contains(t, "BLOB"):
+		return "[]byte"
+	case strings.Contains(t, "DATE"), strings.Contains(t, "TIME"):
+		return "time.Time"
+	}
+
+	return "float64"
+}
+
+// Maps a Postgres information_schema data type to a Go type.
+func postgresGoType(dataType string) string {
+	switch {
+	case dataType == "smallint":
+		return "int16"
+	case dataType == "integer":
+		return "int32"
+	case dataType == "bigint":
+		return "int64"
+	case dataType == "real", dataType == "double precision", dataType == "numeric":
+		return "float64"
+	case dataType == "boolean":
+		return "bool"
+	case dataType == "bytea":
+		return "[]byte"
+	case dataType == "date", strings.HasPrefix(dataType, "timestamp"), strings.HasPrefix(dataType, "time"):
+		return "time.Time"
+	}
+
+	return "string"
+}
+
+var sqlNullTypes = map[string]string{
+	"bool":      "sql.NullBool",
+	"float64":   "sql.NullFloat64",
+	"int16":     "sql.NullInt16",
+	"int32":     "sql.NullInt32",
+	"int64":     "sql.NullInt64",
+	"string":    "sql.NullString",
+	"time.Time": "sql.NullTime",
+}
+
+// Returns the Go type for the column taking nullability into account.
+func (c schemaColumn) goType(options schemaOptions) string {
+	// A nil byte slice already represents NULL.
+	if !c.Nullable || c.Type == "[]byte" {
+		return c.Type
+	}
+
+	if options.Nullable == "sql" {
+		return sqlNullTypes[c.Type]
+	}
+
+	return "*" + c.Type
+}
+
+// Converts a snake_case name to an exported Go name, e.g. user_id becomes UserID.
+func goName(name string, initialisms map[string]bool) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	})
+
+	var b strings.Builder
+
+	for _, part := range parts {
+		if initialisms[strings.ToUpper(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+
+	result := b.String()
+	if result == "" || (result[0] >= '0' && result[0] <= '9') {
+		result = "X" + result
+	}
+
+	return result
+}
+
+// A simple English singular form, e.g. users becomes user and categories becomes category.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"), strings.HasSuffix(name, "is"):
+		// Already singular, e.g. address, status and analysis.
+		return name
+	case strings.HasSuffix(name, "s"):
+		return name[:len(name)-1]
+	}
+
+	return name
+}
+
+// Generates a Go file with a struct for each table.
+// Primary key columns are marked with a // primary key comment.
+// Returns an error when two tables map to the same struct name.
+func generateStructs(tables []schemaTable, options schemaOptions) ([]byte, error) {
+	var body bytes.Buffer
+	imports := map[string]bool{}
+	structTables := map[string]string{}
+
+	for _, table := range tables {
+		structName := table.Name
+		if options.Singularize {
+			structName = singular(structName)
+		}
+
+		structName = goName(structName, options.Initialisms)
+
+		if other, ok := structTables[structName]; ok {
+			return nil, fmt.Errorf("The %s and %s tables both map to the struct %s", other, table.Name, structName)
+		}
+
+		structTables[structName] = table.Name
+
+		fmt.Fprintf(&body, "\n// %s is a row in the %s table.\n", structName, table.Name)
+		fmt.Fprintf(&body, "type %s struct {\n", structName)
+
+		for _, c := range table.Columns {
+			goType := c.goType(options)
+
+			if strings.Contains(goType, "time.") {
+				imports["time"] = true
+			}
+
+			if strings.HasPrefix(goType, "sql.") {
+				imports["database/sql"] = true
+			}
+
+			tags := "db:" + strconv.Quote(c.Name)
+			if options.JSONTags {
+				tags += " json:" + strconv.Quote(c.Name)
+			}
+
+			comment := ""
+			if c.PrimaryKey {
+				comment = " // primary key"
+			}
+
+			fmt.Fprintf(&body, "\t%s %s `%s`%s\n", goName(c.Name, options.Initialisms), goType, tags, comment)
+		}
+
+		fmt.Fprintf(&body, "}\n")
+	}
+
+	var b bytes.Buffer
+
+	// This is intended as a starting point so it isn't marked as generated code that shouldn't be edited.
+	fmt.Fprintf(&b, "// Generated by sqlj-gen schema.\n\n")
+	fmt.Fprintf(&b, "package %s\n", options.Package)
+
+	if len(imports) > 0 {
+		fmt.Fprintf(&b, "\nimport (\n")
+
+		for _, path := range []string{"database/sql", "time"} {
+			if imports[path] {
+				fmt.Fprintf(&b, "\t%q\n", path)
+			}
+		}
+
+		fmt.Fprintf(&b, ")\n")
+	}
+
+	b.Write(body.Bytes())
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JoeAxon/sqlj"
+)
+
+const expectedSchema = `// Generated by sqlj-gen schema.
+
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Category is a row in the categories table.
+type Category struct {
+	ID   int64  ` + "`db:\"id\" json:\"id\"`" + ` // primary key
+	Name string ` + "`db:\"name\" json:\"name\"`" + `
+}
+
+// UserAccount is a row in the user_accounts table.
+type UserAccount struct {
+	ID          int64          ` + "`db:\"id\" json:\"id\"`" + ` // primary key
+	Email       string         ` + "`db:\"email\" json:\"email\"`" + `
+	DisplayName sql.NullString ` + "`db:\"display_name\" json:\"display_name\"`" + `
+	APIKey      []byte         ` + "`db:\"api_key\" json:\"api_key\"`" + `
+	Score       float64        ` + "`db:\"score\" json:\"score\"`" + `
+	Verified    sql.NullBool   ` + "`db:\"verified\" json:\"verified\"`" + `
+	CreatedAt   time.Time      ` + "`db:\"created_at\" json:\"created_at\"`" + `
+	DeletedAt   sql.NullTime   ` + "`db:\"deleted_at\" json:\"deleted_at\"`" + `
+}
+`
+
+func TestSchema(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "schema.db")
+
+	db, err := sqlj.Open("sqlite3", dsn)
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	db.DB.Exec(`
+    CREATE TABLE user_accounts (
+      id integer primary key,
+      email text not null,
+      display_name varchar(255),
+      api_key blob,
+      score real not null,
+      verified boolean,
+      created_at timestamp not null,
+      deleted_at datetime
+    );
+    CREATE TABLE categories (id integer primary key, name text not null);
+  `)
+
+	db.Close()
+
+	var out bytes.Buffer
+
+	if err := runSchema([]string{"-driver", "sqlite3", "-dsn", dsn, "-nullable", "sql", "-json"}, &out); err != nil {
+		t.Fatalf("Failed to generate structs: %s\n", err.Error())
+	}
+
+	if out.String() != expectedSchema {
+		t.Fatalf("Unexpected structs:\n%s\n", out.String())
+	}
+
+	out.Reset()
+
+	if err := runSchema([]string{"-driver", "sqlite3", "-dsn", dsn, "-tables", "user_accounts", "-singular=false"}, &out); err != nil {
+		t.Fatalf("Failed to generate structs: %s\n", err.Error())
+	}
+
+	if !strings.Contains(out.String(), "type UserAccounts struct") || !strings.Contains(out.String(), "DisplayName *string") {
+		t.Fatalf("Unexpected structs:\n%s\n", out.String())
+	}
+
+	if strings.Contains(out.String(), "Category") {
+		t.Fatalf("Expected only the user_accounts table:\n%s\n", out.String())
+	}
+
+	if !strings.Contains(out.String(), "`db:\"id\"` // primary key") || strings.Count(out.String(), "// primary key") != 1 {
+		t.Fatalf("Expected only the id column to be marked as the primary key:\n%s\n", out.String())
+	}
+
+	if err := runSchema([]string{"-driver", "sqlite3", "-dsn", dsn, "-tables", "missing"}, &out); err == nil {
+		t.Fatal("Expected an error for a missing table")
+	}
+
+	tables := []schemaTable{{Name: "user"}, {Name: "users"}}
+
+	if _, err := generateStructs(tables, schemaOptions{Package: "models", Singularize: true}); err == nil || !strings.Contains(err.Error(), "User") {
+		t.Fatalf("Expected an error for tables with the same struct name, got: %v\n", err)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	initialisms := map[string]bool{"ID": true, "URL": true}
+
+	cases := map[string]string{
+		"id":          "ID",
+		"user_id":     "UserID",
+		"profile_url": "ProfileURL",
+		"first_name":  "FirstName",
+		"2fa":         "X2fa",
+	}
+
+	for name, expected := range cases {
+		if result := goName(name, initialisms); result != expected {
+			t.Fatalf("Expected %s to become %s, got: %s\n", name, expected, result)
+		}
+	}
+
+	plurals := map[string]string{
+		"users":      "user",
+		"categories": "category",
+		"addresses":  "address",
+		"boxes":      "box",
+		"access":     "access",
+		"status":     "status",
+		"analysis":   "analysis",
+	}
+
+	for plural, expected := range plurals {
+		if result := singular(plural); result != expected {
+			t.Fatalf("Expected %s to become %s, got: %s\n", plural, expected, result)
+		}
+	}
+}
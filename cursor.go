@@ -0,0 +1,234 @@
+package sqlj
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cursors holds the opaque cursors for the pages either side of a keyset paginated result.
+// Pass Next to .After and Prev to .Before to continue paging.
+type Cursors struct {
+	Next string
+	Prev string
+}
+
+// A single value within an encoded cursor.
+// The kind is recorded so the value can be decoded back into the type it was read as.
+type cursorValue struct {
+	Kind  string          `json:"k"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+func encodeCursor(values []any) (string, error) {
+	encoded := make([]cursorValue, len(values))
+
+	for idx, v := range values {
+		var kind string
+
+		switch v.(type) {
+		case int64:
+			kind = "int"
+		case float64:
+			kind = "float"
+		case bool:
+			kind = "bool"
+		case string:
+			kind = "string"
+		case []byte:
+			kind = "bytes"
+		case time.Time:
+			kind = "time"
+		default:
+			return "", fmt.Errorf("Unsupported cursor value type: %T", v)
+		}
+
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		encoded[idx] = cursorValue{Kind: kind, Value: raw}
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+
+	var encoded []cursorValue
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+
+	values := make([]any, len(encoded))
+
+	for idx, e := range encoded {
+		var err error
+
+		switch e.Kind {
+		case "int":
+			var v int64
+			err = json.Unmarshal(e.Value, &v)
+			values[idx] = v
+		case "float":
+			var v float64
+			err = json.Unmarshal(e.Value, &v)
+			values[idx] = v
+		case "bool":
+			var v bool
+			err = json.Unmarshal(e.Value, &v)
+			values[idx] = v
+		case "string":
+			var v string
+			err = json.Unmarshal(e.Value, &v)
+			values[idx] = v
+		case "bytes":
+			var v []byte
+			err = json.Unmarshal(e.Value, &v)
+			values[idx] = v
+		case "time":
+			var v time.Time
+			err = json.Unmarshal(e.Value, &v)
+			values[idx] = v
+		default:
+			err = errors.New("unknown kind")
+		}
+
+		if err != nil {
+			return nil, errors.New("Invalid cursor")
+		}
+	}
+
+	return values, nil
+}
+
+// Reads the values of the order columns from the given struct pointer.
+// Every order expression must match the db tag of a field on the struct.
+// NULL values are rejected as no row compares greater or less than NULL, so paging would silently stop.
+func cursorValues(v any, orders []orderBy) ([]any, error) {
+	fields := extractFields(v)
+	values := make([]any, len(orders))
+
+	for idx, o := range orders {
+		var found field
+
+		for _, f := range fields {
+			if f.GetName() == o.Expression {
+				found = f
+				break
+			}
+		}
+
+		if found == nil {
+			return nil, fmt.Errorf("Order expression %q does not match a db field", o.Expression)
+		}
+
+		// The converter dereferences the field pointers returned by extractFields.
+		value, err := driver.DefaultParameterConverter.ConvertValue(found.GetValue())
+		if err != nil {
+			return nil, err
+		}
+
+		if value == nil {
+			return nil, fmt.Errorf("Order column %q is NULL, keyset pagination requires columns that are not null", o.Expression)
+		}
+
+		values[idx] = value
+	}
+
+	return values, nil
+}
+
+func isDescending(direction string) bool {
+	return strings.EqualFold(strings.TrimSpace(direction), "DESC")
+}
+
+func reverseOrder(orders []orderBy) []orderBy {
+	reversed := make([]orderBy, len(orders))
+
+	for idx, o := range orders {
+		direction := "DESC"
+		if isDescending(o.Direction) {
+			direction = "ASC"
+		}
+
+		reversed[idx] = orderBy{Expression: o.Expression, Direction: direction}
+	}
+
+	return reversed
+}
+
+// Builds the seek predicate that selects the rows after the cursor values for the given ordering.
+// When every column sorts in the same direction a row value comparison is used, e.g. (a, b) > (?, ?).
+// Mixed directions expand to: a > ? OR (a = ? AND b < ?).
+func buildSeekExpr(orders []orderBy, values []any) (Expr, []any) {
+	ops := make([]string, len(orders))
+	uniform := true
+
+	for idx, o := range orders {
+		ops[idx] = ">"
+		if isDescending(o.Direction) {
+			ops[idx] = "<"
+		}
+
+		if ops[idx] != ops[0] {
+			uniform = false
+		}
+	}
+
+	if uniform {
+		if len(orders) == 1 {
+			return SimpleExpr{fmt.Sprintf("%s %s ?", orders[0].Expression, ops[0])}, values
+		}
+
+		columns := make([]string, len(orders))
+		placeholders := make([]string, len(orders))
+
+		for idx, o := range orders {
+			columns[idx] = o.Expression
+			placeholders[idx] = "?"
+		}
+
+		expr := fmt.Sprintf("%s %s %s", parens(strings.Join(columns, ", ")), ops[0], parens(strings.Join(placeholders, ", ")))
+
+		return SimpleExpr{expr}, values
+	}
+
+	clauses := make([]WhereClause, len(orders))
+	seekValues := []any{}
+
+	for idx, o := range orders {
+		conditions := make([]string, idx+1)
+
+		for j := 0; j < idx; j++ {
+			conditions[j] = columnEq(orders[j].Expression)
+			seekValues = append(seekValues, values[j])
+		}
+
+		conditions[idx] = fmt.Sprintf("%s %s ?", o.Expression, ops[idx])
+		seekValues = append(seekValues, values[idx])
+
+		expr := conditions[0]
+		if len(conditions) > 1 {
+			expr = parens(strings.Join(conditions, " AND "))
+		}
+
+		clauses[idx] = WhereClause{OR_TYPE, SimpleExpr{expr}}
+	}
+
+	return NestedExpr{clauses}, seekValues
+}
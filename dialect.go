@@ -0,0 +1,33 @@
+package sqlj
+
+// Identifies the SQL dialect spoken by the database.
+// This allows sqlj to make use of features that aren't available everywhere.
+// The zero value makes no assumptions beyond the SQL used by every other query.
+type Dialect string
+
+const (
+	PostgresDialect Dialect = "postgres"
+	SQLiteDialect   Dialect = "sqlite"
+)
+
+// Returns the dialect for a database/sql driver name or an empty Dialect if it isn't recognised.
+func dialectFromDriver(driver string) Dialect {
+	switch driver {
+	case "postgres", "pgx":
+		return PostgresDialect
+	case "sqlite3", "sqlite":
+		return SQLiteDialect
+	}
+
+	return ""
+}
+
+// Window functions such as count(1) OVER () are supported by PostgreSQL and SQLite 3.25+.
+func (d Dialect) supportsWindowFunctions() bool {
+	return d == PostgresDialect || d == SQLiteDialect
+}
+
+// INSERT ... ON CONFLICT DO NOTHING is supported by PostgreSQL 9.5+ and SQLite 3.24+.
+func (d Dialect) supportsUpsert() bool {
+	return d == PostgresDialect || d == SQLiteDialect
+}
@@ -86,20 +86,20 @@ func pluckValues(fields []field) []any {
 	return values[:n]
 }
 
-// TODO: Rewrite this so it's deterministic. Currently the order the fields is changed.
+// Removes fields with duplicate names, later fields replace earlier ones.
+// Each field keeps the position of the first field with its name.
 func dedupeFields(fields []field) []field {
-	indexedFields := make(map[string]field)
+	positions := make(map[string]int)
+	allFields := make([]field, 0, len(fields))
 
 	for _, f := range fields {
-		indexedFields[f.GetName()] = f
-	}
-
-	allFields := make([]field, len(indexedFields))
+		if idx, ok := positions[f.GetName()]; ok {
+			allFields[idx] = f
+			continue
+		}
 
-	n := 0
-	for _, v := range indexedFields {
-		allFields[n] = v
-		n++
+		positions[f.GetName()] = len(allFields)
+		allFields = append(allFields, f)
 	}
 
 	return allFields
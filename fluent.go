@@ -1,6 +1,13 @@
 package sqlj
 
-import "errors"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
 
 type QueryDB struct {
 	DB           *DB
@@ -8,6 +15,16 @@ type QueryDB struct {
 	OrderClauses []orderBy
 	WhereClauses []WhereClause
 	WhereValues  []any
+
+	limit      uint
+	offset     uint
+	distinct   bool
+	distinctOn []string
+	after      string
+	before     string
+	deleted    deletedScope
+	model      reflect.Type // Set by .Model
+	err        error        // An error starting the query, returned when it is executed
 }
 
 func (q QueryDB) Where(expr string, values ...any) QueryDB {
@@ -26,7 +43,7 @@ func (q QueryDB) WhereExpr(expr Expr, values ...any) QueryDB {
 }
 
 func (q QueryDB) OrWhere(expr string, values ...any) QueryDB {
-	return q.OrWhereExpr(SimpleExpr{expr})
+	return q.OrWhereExpr(SimpleExpr{expr}, values...)
 }
 
 func (q QueryDB) OrWhereExpr(expr Expr, values ...any) QueryDB {
@@ -49,28 +66,63 @@ func (q QueryDB) Order(expression string, direction string) QueryDB {
 	return q
 }
 
-// Get a record by ID.
-// This will ignore any previous calls to .Where and .OrWhere
-func (q QueryDB) Get(id any, v any) error {
-	if err := checkValueType(v); err != nil {
-		return err
-	}
+// Limits the number of records returned by .All.
+// .One always applies a limit of 1 and .Page replaces the limit with the page size.
+func (q QueryDB) Limit(n uint) QueryDB {
+	q.limit = n
 
-	fields := extractFields(v)
-	columns := pluckNames(fields)
+	return q
+}
 
-	sql := buildSelectQuery(selectParams{
-		Columns: columns,
-		From:    q.From,
-		Where: []WhereClause{
-			{AND_TYPE, SimpleExpr{columnEq(q.DB.getIDName())}},
-		},
-	})
+// Skips the first n records returned by .One and .All.
+// .Page replaces the offset with the start of the page.
+func (q QueryDB) Offset(n uint) QueryDB {
+	q.offset = n
+
+	return q
+}
+
+// Removes duplicate records with SELECT DISTINCT.
+func (q QueryDB) Distinct() QueryDB {
+	q.distinct = true
+
+	return q
+}
+
+// Keeps the first record for each distinct value of the given columns with SELECT DISTINCT ON.
+// This is only supported by PostgreSQL and the .Order clauses should start with the same columns.
+func (q QueryDB) DistinctOn(columns ...string) QueryDB {
+	q.distinctOn = append(append([]string{}, q.distinctOn...), columns...)
+
+	return q
+}
+
+// Continues a keyset paginated query from the row after the cursor.
+// The cursor should come from .Cursors on a query with the same .Order clauses.
+func (q QueryDB) After(cursor string) QueryDB {
+	q.after = cursor
+	q.before = ""
+
+	return q
+}
+
+// Continues a keyset paginated query from the row before the cursor.
+// The cursor should come from .Cursors on a query with the same .Order clauses.
+func (q QueryDB) Before(cursor string) QueryDB {
+	q.before = cursor
+	q.after = ""
 
-	return q.DB.GetRow(sql, v, id)
+	return q
+}
+
+// Get a record by ID.
+// This will ignore any previous calls to .Where and .OrWhere
+func (q QueryDB) Get(id any, v any) error {
+	return q.DB.Get(q.From, id, v)
 }
 
 // Get a single record from the given table.
+// A LIMIT 1 is applied to the query.
 func (q QueryDB) One(v any) error {
 	if err := checkValueType(v); err != nil {
 		return err
@@ -79,13 +131,15 @@ func (q QueryDB) One(v any) error {
 	fields := extractFields(v)
 	columns := pluckNames(fields)
 
-	sql := buildSelectQuery(selectParams{
-		Columns: columns,
-		From:    q.From,
-		Where:   q.WhereClauses,
-	})
+	q = q.scoped(reflect.TypeOf(v).Elem())
+	q.limit = 1
 
-	return q.DB.GetRow(sql, v, q.WhereValues...)
+	sql, values, err := q.selectQuery(columns)
+	if err != nil {
+		return err
+	}
+
+	return q.DB.getRow(QueryEvent{GetOperation, q.From, sql, values}, v)
 }
 
 // Select all data from the query object.
@@ -100,14 +154,170 @@ func (q QueryDB) All(v any) error {
 	fields := extractFields(structInstance)
 	columns := pluckNames(fields)
 
+	q = q.scoped(reflect.TypeOf(structInstance).Elem())
+
+	sql, values, err := q.selectQuery(columns)
+	if err != nil {
+		return err
+	}
+
+	slice := reflect.ValueOf(v).Elem()
+	start := slice.Len()
+
+	if err := q.DB.selectAll(QueryEvent{SelectOperation, q.From, sql, values}, v); err != nil {
+		return err
+	}
+
+	q.restoreOrder(slice, start)
+
+	return nil
+}
+
+// Rows before a .Before cursor are fetched in reverse order so the limit applies
+// to the rows closest to the cursor. This flips the rows appended to the slice from start back.
+func (q QueryDB) restoreOrder(slice reflect.Value, start int) {
+	if q.before == "" {
+		return
+	}
+
+	swap := reflect.Swapper(slice.Interface())
+	for i, j := start, slice.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
+
+// Returns the cursors for the pages either side of v.
+// v must be a pointer to a slice of structs retrieved by this query.
+// The .Order expressions must match db fields on the struct and should
+// include a unique column, such as the ID, so that every row has a distinct position.
+func (q QueryDB) Cursors(v any) (Cursors, error) {
+	if _, err := getSliceStructInstance(v); err != nil {
+		return Cursors{}, err
+	}
+
+	if len(q.OrderClauses) == 0 {
+		return Cursors{}, errors.New("Cursors require at least one .Order clause")
+	}
+
+	slice := reflect.ValueOf(v).Elem()
+	if slice.Len() == 0 {
+		return Cursors{}, nil
+	}
+
+	firstValues, err := cursorValues(slice.Index(0).Addr().Interface(), q.OrderClauses)
+	if err != nil {
+		return Cursors{}, err
+	}
+
+	lastValues, err := cursorValues(slice.Index(slice.Len()-1).Addr().Interface(), q.OrderClauses)
+	if err != nil {
+		return Cursors{}, err
+	}
+
+	prev, err := encodeCursor(firstValues)
+	if err != nil {
+		return Cursors{}, err
+	}
+
+	next, err := encodeCursor(lastValues)
+	if err != nil {
+		return Cursors{}, err
+	}
+
+	return Cursors{Next: next, Prev: prev}, nil
+}
+
+// Returns the SQL and values that .All would execute without executing them.
+// The columns are normally taken from the struct passed to .One or .All so * is selected instead.
+func (q QueryDB) ToSQL() (string, []any, error) {
+	return q.scoped(nil).selectQuery([]string{"*"})
+}
+
+// Returns the SQL from .ToSQL with the values interpolated for the DB dialect.
+// This is intended for logging and debugging only. The result should never be executed.
+func (q QueryDB) DebugString() string {
+	sql, values, err := q.ToSQL()
+	if err != nil {
+		return fmt.Sprintf("Invalid query: %s", err.Error())
+	}
+
+	return Interpolate(sql, values, q.DB.Dialect)
+}
+
+// Builds the SQL and values to select the given columns using every clause on the query.
+func (q QueryDB) selectQuery(columns []string) (string, []any, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	where, orders, values, err := q.seek()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if q.limit > 0 {
+		values = append(values, q.limit)
+	}
+
+	if q.offset > 0 {
+		values = append(values, q.offset)
+	}
+
 	sql := buildSelectQuery(selectParams{
-		From:    q.From,
-		Where:   q.WhereClauses,
-		OrderBy: q.OrderClauses,
-		Columns: columns,
+		From:       q.From,
+		Where:      where,
+		OrderBy:    orders,
+		Columns:    columns,
+		Distinct:   q.distinct,
+		DistinctOn: q.distinctOn,
+		Limit:      q.limit > 0,
+		Offset:     q.offset > 0,
+		Dialect:    q.DB.Dialect,
 	})
 
-	return q.DB.SelectAll(sql, v, q.WhereValues...)
+	return sql, values, nil
+}
+
+// Combines the where clauses with the seek predicate from the .After or .Before cursor.
+// Returns the where clauses, the ordering to apply and the values for the placeholders.
+func (q QueryDB) seek() ([]WhereClause, []orderBy, []any, error) {
+	cursor := q.after
+	orders := q.OrderClauses
+
+	if q.before != "" {
+		cursor = q.before
+		orders = reverseOrder(q.OrderClauses)
+	}
+
+	values := append([]any{}, q.WhereValues...)
+
+	if cursor == "" {
+		return q.WhereClauses, orders, values, nil
+	}
+
+	if len(orders) == 0 {
+		return nil, nil, nil, errors.New("Keyset pagination requires at least one .Order clause")
+	}
+
+	keys, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(keys) != len(orders) {
+		return nil, nil, nil, errors.New("Cursor does not match the .Order clauses")
+	}
+
+	seekExpr, seekValues := buildSeekExpr(orders, keys)
+
+	where := []WhereClause{}
+	if len(q.WhereClauses) > 0 {
+		where = append(where, WhereClause{AND_TYPE, NestedExpr{q.WhereClauses}})
+	}
+
+	where = append(where, WhereClause{AND_TYPE, seekExpr})
+
+	return where, orders, append(values, seekValues...), nil
 }
 
 // Selects a page of data from the given table.
@@ -115,55 +325,338 @@ func (q QueryDB) All(v any) error {
 // The results will be marshalled into the v slice of structs.
 // v must be a pointer to a slice of structs.
 func (q QueryDB) Page(page uint, pageSize uint, v any) error {
-	if page < 1 {
-		return errors.New("Page number must be greater than 0")
+	structInstance, err := getSliceStructInstance(v)
+	if err != nil {
+		return err
 	}
 
-	if pageSize < 1 {
-		return errors.New("Page size must be greater than 0")
+	fields := extractFields(structInstance)
+	columns := pluckNames(fields)
+
+	q = q.scoped(reflect.TypeOf(structInstance).Elem())
+
+	sql, values, err := q.pageQuery(page, pageSize, columns)
+	if err != nil {
+		return err
+	}
+
+	return q.DB.selectAll(QueryEvent{SelectOperation, q.From, sql, values}, v)
+}
+
+// Describes a page of results retrieved by .Paginate.
+type PageInfo struct {
+	Page       uint
+	PageSize   uint
+	Total      uint
+	TotalPages uint
+	HasNext    bool
+	HasPrev    bool
+}
+
+func newPageInfo(page uint, pageSize uint, total uint) PageInfo {
+	totalPages := (total + pageSize - 1) / pageSize
+
+	return PageInfo{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
 	}
+}
 
+// Selects a page of data like .Page and returns the page details alongside it.
+// When the DB dialect supports window functions the total is counted with count(1) OVER ()
+// in the same query, otherwise a separate .Count query is made.
+// A separate count is also made for .Distinct and .DistinctOn queries as the window counts the rows before duplicates are removed.
+// The results will be marshalled into the v slice of structs.
+// v must be a pointer to a slice of structs.
+func (q QueryDB) Paginate(page uint, pageSize uint, v any) (PageInfo, error) {
 	structInstance, err := getSliceStructInstance(v)
 	if err != nil {
-		return err
+		return PageInfo{}, err
+	}
+
+	t := reflect.TypeOf(structInstance).Elem()
+
+	if !q.DB.Dialect.supportsWindowFunctions() || q.isDistinct() {
+		if err := q.Page(page, pageSize, v); err != nil {
+			return PageInfo{}, err
+		}
+
+		total, err := q.count(t)
+		if err != nil {
+			return PageInfo{}, err
+		}
+
+		return newPageInfo(page, pageSize, total), nil
 	}
 
 	fields := extractFields(structInstance)
-	columns := pluckNames(fields)
+	columns := append(pluckNames(fields), "count(1) OVER ()")
+
+	query, values, err := q.scoped(t).pageQuery(page, pageSize, columns)
+	if err != nil {
+		return PageInfo{}, err
+	}
+
+	slice := reflect.ValueOf(v).Elem()
+	start := slice.Len()
+
+	var total uint
+
+	err = q.DB.query(QueryEvent{SelectOperation, q.From, query, values}, func(rows *sql.Rows) (int64, error) {
+		return scanRowsIntoStructs(rows, v, &total)
+	})
+
+	if err != nil {
+		return PageInfo{}, err
+	}
+
+	if err := q.DB.afterFindAll(v, int64(slice.Len()-start)); err != nil {
+		return PageInfo{}, err
+	}
+
+	// A page past the end has no rows to carry the total so it has to be counted separately.
+	if slice.Len() == start && page > 1 {
+		if total, err = q.count(t); err != nil {
+			return PageInfo{}, err
+		}
+	}
+
+	return newPageInfo(page, pageSize, total), nil
+}
+
+// Builds the SQL and values to select a page of the given columns.
+func (q QueryDB) pageQuery(page uint, pageSize uint, columns []string) (string, []any, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	if page < 1 {
+		return "", nil, errors.New("Page number must be greater than 0")
+	}
+
+	if pageSize < 1 {
+		return "", nil, errors.New("Page size must be greater than 0")
+	}
 
 	offset := (page - 1) * pageSize
 	limit := pageSize
 
 	sql := buildSelectQuery(selectParams{
-		From:    q.From,
-		Where:   q.WhereClauses,
-		OrderBy: q.OrderClauses,
-		Columns: columns,
-		Offset:  true,
-		Limit:   true,
+		From:       q.From,
+		Where:      q.WhereClauses,
+		OrderBy:    q.OrderClauses,
+		Columns:    columns,
+		Distinct:   q.distinct,
+		DistinctOn: q.distinctOn,
+		Offset:     true,
+		Limit:      true,
+		Dialect:    q.DB.Dialect,
 	})
 
-	values := append(q.WhereValues, limit, offset)
+	values := append(append([]any{}, q.WhereValues...), limit, offset)
 
-	return q.DB.SelectAll(sql, v, values...)
+	return sql, values, nil
 }
 
 // Counts the number of records in the table.
 // This is intended to be used in conjunction with .Page.
+// Soft deleted rows are excluded when the model registered for the table has a softdelete field.
 func (q QueryDB) Count() (uint, error) {
+	return q.count(nil)
+}
+
+// Counts the records in scope for the struct type t, which may be nil.
+// A distinct query is counted as a subquery selecting the columns of t, or every column when t isn't known.
+func (q QueryDB) count(t reflect.Type) (uint, error) {
 	var count uint = 0
 
-	sql := buildSelectQuery(selectParams{
+	if q.err != nil {
+		return 0, q.err
+	}
+
+	if t == nil {
+		t = q.model
+	}
+
+	q = q.scoped(t)
+
+	query := buildSelectQuery(selectParams{
 		From:    q.From,
 		Where:   q.WhereClauses,
 		Columns: []string{"count(1)"},
 	})
 
-	result := q.DB.DB.QueryRow(sql, q.WhereValues...)
+	if q.isDistinct() {
+		columns := q.distinctOn
+		if len(columns) == 0 {
+			columns = []string{"*"}
+
+			if t != nil {
+				columns = structColumns(t)
+			}
+		}
+
+		subquery := buildSelectQuery(selectParams{
+			From:       q.From,
+			Where:      q.WhereClauses,
+			Columns:    columns,
+			Distinct:   q.distinct,
+			DistinctOn: q.distinctOn,
+			Dialect:    q.DB.Dialect,
+		})
+
+		query = "SELECT count(1) FROM " + parens(subquery) + " AS distinct_rows"
+	}
 
-	if err := result.Scan(&count); err != nil {
+	if err := q.DB.scalar(QueryEvent{CountOperation, q.From, query, q.WhereValues}, &count); err != nil {
 		return 0, err
 	}
 
 	return count, nil
 }
+
+func (q QueryDB) isDistinct() bool {
+	return q.distinct || len(q.distinctOn) > 0
+}
+
+// Reports whether the query matches any records with SELECT EXISTS, which stops at the first match.
+func (q QueryDB) Exists() (bool, error) {
+	return q.exists(nil)
+}
+
+// Checks for records in scope for the struct type t, which may be nil.
+func (q QueryDB) exists(t reflect.Type) (bool, error) {
+	var exists bool
+
+	if q.err != nil {
+		return false, q.err
+	}
+
+	q = q.scoped(t)
+
+	subquery := buildSelectQuery(selectParams{
+		From:    q.From,
+		Where:   q.WhereClauses,
+		Columns: []string{"1"},
+	})
+
+	query := "SELECT EXISTS" + parens(subquery)
+
+	if err := q.DB.scalar(QueryEvent{ExistsOperation, q.From, query, q.WhereValues}, &exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Gets the first record matching the query into v like .One.
+// When nothing matches the fields of v matched by simple column = ? conditions, e.g. .Where("email = ?", email),
+// are set to their values so v is ready to be inserted, and false is returned.
+// No fields are set when the conditions are combined with .OrWhere.
+// v must be a pointer to a struct.
+func (q QueryDB) FirstOrInit(v any) (bool, error) {
+	err := q.One(v)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, q.assignConditions(v)
+	}
+
+	return err == nil, err
+}
+
+var equalityCondition = regexp.MustCompile(`^\s*(\w+)\s*=\s*\?\s*$`)
+
+// Sets the fields of v matched by the column = ? conditions of the query to their values.
+func (q QueryDB) assignConditions(v any) error {
+	for idx, c := range q.WhereClauses {
+		if idx > 0 && c.Type == OR_TYPE {
+			return nil
+		}
+	}
+
+	value := reflect.ValueOf(v).Elem()
+	meta := getStructMeta(value.Type())
+	offset := 0
+
+	for _, c := range q.WhereClauses {
+		expr := c.Expr.String()
+
+		if match := equalityCondition.FindStringSubmatch(expr); match != nil && offset < len(q.WhereValues) {
+			if idx, ok := meta.byName[match[1]]; ok {
+				if err := setField(value.FieldByIndex(meta.fields[idx].index), q.WhereValues[offset]); err != nil {
+					return fmt.Errorf("Cannot set the %s field from the query: %s", match[1], err.Error())
+				}
+			}
+		}
+
+		offset += strings.Count(expr, "?")
+	}
+
+	return nil
+}
+
+// Sets the field to the value, converting it like valuesEqual. Pointer fields are set to a pointer to the value.
+func setField(field reflect.Value, value any) error {
+	v := reflect.ValueOf(value)
+
+	if !v.IsValid() {
+		field.SetZero()
+
+		return nil
+	}
+
+	if field.Kind() == reflect.Ptr && v.Type() != field.Type() {
+		elem := reflect.New(field.Type().Elem())
+
+		if err := setField(elem.Elem(), value); err != nil {
+			return err
+		}
+
+		field.Set(elem)
+
+		return nil
+	}
+
+	if v.Type() != field.Type() {
+		converted, ok := convertValue(v, field.Type())
+		if !ok {
+			return fmt.Errorf("%T is not assignable to %s", value, field.Type())
+		}
+
+		v = converted
+	}
+
+	field.Set(v)
+
+	return nil
+}
+
+// Gets the first record matching the query into v like .One, or inserts v when nothing matches.
+// The fields matched by the query are set first, see .FirstOrInit. Reports whether v was inserted.
+// When the dialect supports it the insert uses ON CONFLICT DO NOTHING and the record is read again if
+// another insert got there first. This is only race-safe when a unique constraint covers the matched columns.
+// In that case BeforeInsert has already been called on v but AfterInsert isn't, as nothing was inserted.
+// v must be a pointer to a struct.
+func (q QueryDB) FirstOrCreate(v any) (bool, error) {
+	found, err := q.FirstOrInit(v)
+	if found || err != nil {
+		return false, err
+	}
+
+	upsert := q.DB.Dialect.supportsUpsert()
+
+	err = q.DB.writeRow(InsertOperation, q.From, v, func() (string, []any, error) {
+		return q.DB.buildInsert(q.From, v, map[string]string{}, upsert)
+	})
+
+	// Nothing is returned when the insert conflicts with a record inserted since the lookup.
+	if upsert && errors.Is(err, sql.ErrNoRows) {
+		return false, q.One(v)
+	}
+
+	return err == nil, err
+}
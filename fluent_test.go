@@ -1,7 +1,9 @@
 package sqlj
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -108,6 +110,36 @@ func TestFrom(t *testing.T) {
 	}
 }
 
+func TestOrWhere(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec(`
+    CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp);
+    INSERT INTO user (name, email, created_at) VALUES
+      ('Jess', 'jess@example.com', date()),
+      ('Joe', 'joe@example.com', date()),
+      ('Jane', 'jane@example.com', date());
+  `)
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	var users []User
+
+	if err := jdb.From("user").Where("name = ?", "Jess").OrWhere("email = ?", "jane@example.com").Order("id", "ASC").All(&users); err != nil {
+		t.Fatalf("Failed to select users: %s\n", err.Error())
+	}
+
+	if len(users) != 2 || users[0].Name != "Jess" || users[1].Name != "Jane" {
+		t.Fatalf("Expected the users matching either condition, got: %v\n", users)
+	}
+}
+
 func TestPage(t *testing.T) {
 	db, err := sql.Open("sqlite3", ":memory:")
 
@@ -184,3 +216,366 @@ func TestPage(t *testing.T) {
 		t.Fatalf("Expected first user to be 'Joe', got: %s\n", secondPage[0].Name)
 	}
 }
+
+func TestKeysetPagination(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec(`
+    CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp);
+  `)
+
+	db.Exec(`
+    INSERT INTO user (name, email, created_at) VALUES
+      ('Adam', 'adam@example.com', date()),
+      ('Jess', 'jess@example.com', date()),
+      ('Joe', 'joe@example.com', date()),
+      ('Jane', 'jane@example.com', date()),
+      ('Jen', 'jen@example.com', date()),
+      ('Jess', 'jess2@example.com', date()),
+      ('Jacob', 'jacob@example.com', date());
+  `)
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	userQuery := jdb.From("user").Where("name <> ?", "Adam").Order("name", "DESC").Order("id", "ASC")
+
+	var firstPage []User
+
+	if err := userQuery.Limit(3).All(&firstPage); err != nil {
+		t.Fatalf("Failed to get first page of users: %s\n", err.Error())
+	}
+
+	if len(firstPage) != 3 || firstPage[0].Name != "Joe" || firstPage[2].Email != "jess2@example.com" {
+		t.Fatalf("Unexpected first page: %v\n", firstPage)
+	}
+
+	cursors, err := userQuery.Cursors(&firstPage)
+
+	if err != nil {
+		t.Fatalf("Failed to get cursors: %s\n", err.Error())
+	}
+
+	var secondPage []User
+
+	if err := userQuery.After(cursors.Next).Limit(3).All(&secondPage); err != nil {
+		t.Fatalf("Failed to get second page of users: %s\n", err.Error())
+	}
+
+	if len(secondPage) != 3 || secondPage[0].Name != "Jen" || secondPage[2].Name != "Jacob" {
+		t.Fatalf("Unexpected second page: %v\n", secondPage)
+	}
+
+	cursors, err = userQuery.Cursors(&secondPage)
+
+	if err != nil {
+		t.Fatalf("Failed to get cursors: %s\n", err.Error())
+	}
+
+	var previousPage []User
+
+	if err := userQuery.Before(cursors.Prev).Limit(2).All(&previousPage); err != nil {
+		t.Fatalf("Failed to get previous page of users: %s\n", err.Error())
+	}
+
+	if len(previousPage) != 2 || previousPage[0].Email != "jess@example.com" || previousPage[1].Email != "jess2@example.com" {
+		t.Fatalf("Unexpected previous page: %v\n", previousPage)
+	}
+
+	var lastPage []User
+
+	cursors, _ = userQuery.Cursors(&secondPage)
+
+	if err := userQuery.After(cursors.Next).Limit(3).All(&lastPage); err != nil {
+		t.Fatalf("Failed to get last page of users: %s\n", err.Error())
+	}
+
+	if len(lastPage) != 0 {
+		t.Fatalf("Expected 0 rows, got: %d\n", len(lastPage))
+	}
+
+	if err := userQuery.After("not a cursor").All(&lastPage); err == nil {
+		t.Fatal("Expected an error for an invalid cursor")
+	}
+
+	contacts := []Contact{{ID: 1}}
+
+	if _, err := jdb.From("contact").Order("nickname", "ASC").Cursors(&contacts); err == nil {
+		t.Fatal("Expected an error for a NULL cursor value")
+	}
+
+	// A cursor holding NULL would compare false against every row.
+	nullCursor := base64.RawURLEncoding.EncodeToString([]byte(`[{"k":"null"},{"k":"null"}]`))
+
+	if err := userQuery.After(nullCursor).All(&lastPage); err == nil {
+		t.Fatal("Expected an error for a cursor with a NULL value")
+	}
+}
+
+type Contact struct {
+	ID       uint    `db:"id"`
+	Nickname *string `db:"nickname"`
+}
+
+func TestPaginate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec(`
+    CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp);
+  `)
+
+	db.Exec(`
+    INSERT INTO user (name, email, created_at) VALUES
+      ('Adam', 'adam@example.com', date()),
+      ('Jess', 'jess@example.com', date()),
+      ('Joe', 'joe@example.com', date()),
+      ('Jane', 'jane@example.com', date()),
+      ('Jen', 'jen@example.com', date());
+  `)
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	for _, dialect := range []Dialect{"", SQLiteDialect} {
+		jdb := NewDB(db)
+		jdb.Dialect = dialect
+
+		userQuery := jdb.From("user").Where("name <> ?", "Adam").Order("name", "ASC")
+
+		var firstPage []User
+
+		info, err := userQuery.Paginate(1, 3, &firstPage)
+
+		if err != nil {
+			t.Fatalf("Failed to paginate users: %s\n", err.Error())
+		}
+
+		if len(firstPage) != 3 || firstPage[0].Name != "Jane" {
+			t.Fatalf("Unexpected first page: %v\n", firstPage)
+		}
+
+		expected := PageInfo{Page: 1, PageSize: 3, Total: 4, TotalPages: 2, HasNext: true, HasPrev: false}
+
+		if info != expected {
+			t.Fatalf("Expected page info %+v, got: %+v\n", expected, info)
+		}
+
+		var pastEnd []User
+
+		info, err = userQuery.Paginate(3, 3, &pastEnd)
+
+		if err != nil {
+			t.Fatalf("Failed to paginate users: %s\n", err.Error())
+		}
+
+		expected = PageInfo{Page: 3, PageSize: 3, Total: 4, TotalPages: 2, HasNext: false, HasPrev: true}
+
+		if len(pastEnd) != 0 || info != expected {
+			t.Fatalf("Expected page info %+v, got: %+v\n", expected, info)
+		}
+
+		if _, err := userQuery.Paginate(0, 3, &pastEnd); err == nil {
+			t.Fatal("Expected an error for page 0")
+		}
+	}
+}
+
+func TestLimitOffsetAndDistinct(t *testing.T) {
+	jdb, err := Open("sqlite3", ":memory:")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	defer jdb.Close()
+
+	jdb.DB.Exec(`
+    CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp);
+  `)
+
+	jdb.DB.Exec(`
+    INSERT INTO user (name, email, created_at) VALUES
+      ('Jess', 'jess@example.com', date()),
+      ('Joe', 'joe@example.com', date()),
+      ('Jane', 'jane@example.com', date()),
+      ('Joe', 'joe2@example.com', date());
+  `)
+
+	var limited []User
+	if err := jdb.From("user").Order("id", "ASC").Limit(2).All(&limited); err != nil {
+		t.Fatalf("Failed to select limited users: %s\n", err.Error())
+	}
+
+	if len(limited) != 2 || limited[0].Name != "Jess" {
+		t.Fatalf("Unexpected limited users: %v\n", limited)
+	}
+
+	var offset []User
+	if err := jdb.From("user").Order("id", "ASC").Offset(3).All(&offset); err != nil {
+		t.Fatalf("Failed to select offset users: %s\n", err.Error())
+	}
+
+	if len(offset) != 1 || offset[0].Email != "joe2@example.com" {
+		t.Fatalf("Unexpected offset users: %v\n", offset)
+	}
+
+	var second User
+	if err := jdb.From("user").Where("name = ?", "Joe").Order("id", "DESC").One(&second); err != nil {
+		t.Fatalf("Failed to get user: %s\n", err.Error())
+	}
+
+	if second.Email != "joe2@example.com" {
+		t.Fatalf("Expected the last Joe, got: %s\n", second.Email)
+	}
+
+	type Name struct {
+		Name string `db:"name"`
+	}
+
+	var names []Name
+	if err := jdb.From("user").Distinct().Order("name", "ASC").All(&names); err != nil {
+		t.Fatalf("Failed to select distinct names: %s\n", err.Error())
+	}
+
+	if len(names) != 3 {
+		t.Fatalf("Expected 3 distinct names, got: %d\n", len(names))
+	}
+
+	// The total counts the distinct names rather than the 4 rows.
+	var page []Name
+	info, err := jdb.From("user").Distinct().Order("name", "ASC").Paginate(2, 2, &page)
+
+	if err != nil {
+		t.Fatalf("Failed to paginate distinct names: %s\n", err.Error())
+	}
+
+	if len(page) != 1 || info.Total != 3 || info.TotalPages != 2 || info.HasNext {
+		t.Fatalf("Unexpected distinct page: %v %+v\n", page, info)
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	jdb := NewDB(nil)
+
+	sql, values, err := jdb.From("user").Where("name = ?", "Joe").OrWhere("email = ?", "joe@example.com").Order("id", "ASC").Limit(10).ToSQL()
+
+	if err != nil {
+		t.Fatalf("Failed to build SQL: %s\n", err.Error())
+	}
+
+	if sql != "SELECT * FROM user WHERE name = $1 OR email = $2 ORDER BY id ASC LIMIT $3" {
+		t.Fatalf("Unexpected SQL: %s\n", sql)
+	}
+
+	if len(values) != 3 || values[0] != "Joe" || values[2] != uint(10) {
+		t.Fatalf("Unexpected values: %v\n", values)
+	}
+}
+
+// Inserts a competing row just before it is inserted itself.
+type RacingUser struct {
+	ID    uint   `db:"id"`
+	Name  string `db:"name"`
+	Email string `db:"email"`
+
+	db       *sql.DB
+	inserted bool
+}
+
+func (u *RacingUser) BeforeInsert(ctx context.Context) error {
+	_, err := u.db.Exec("INSERT INTO user (name, email) VALUES ('Winner', $1)", u.Email)
+	return err
+}
+
+func (u *RacingUser) AfterInsert(ctx context.Context) error {
+	u.inserted = true
+	return nil
+}
+
+func TestExistsAndFirstOrCreate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	// Every connection to :memory: opens a separate database.
+	db.SetMaxOpenConns(1)
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text unique, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	hook := &recordingHook{}
+
+	jdb := NewDB(db)
+	jdb.Dialect = SQLiteDialect
+	jdb.Hook = hook
+
+	if exists, err := jdb.From("user").Where("email = ?", "joe@example.com").Exists(); err != nil || exists {
+		t.Fatalf("Expected no user to exist: %v\n", err)
+	}
+
+	if hook.before[0].SQL != "SELECT EXISTS(SELECT 1 FROM user WHERE email = $1)" {
+		t.Fatalf("Unexpected exists SQL: %s\n", hook.before[0].SQL)
+	}
+
+	query := jdb.From("user").Where("email = ?", "joe@example.com")
+
+	user := User{Name: "Joe"}
+
+	if found, err := query.FirstOrInit(&user); err != nil || found || user.ID != 0 || user.Email != "joe@example.com" {
+		t.Fatalf("Expected the user to be initialised from the query: %v %+v\n", err, user)
+	}
+
+	either := User{}
+
+	if found, err := query.OrWhere("name = ?", "Joe").FirstOrInit(&either); err != nil || found || either.Email != "" {
+		t.Fatalf("Expected no fields to be set from OR conditions: %v %+v\n", err, either)
+	}
+
+	if _, err := jdb.From("user").Where("id = ?", "one").FirstOrInit(&either); err == nil {
+		t.Fatal("Expected an error setting a field from a value of another type")
+	}
+
+	created, err := query.FirstOrCreate(&user)
+
+	if err != nil || !created || user.ID == 0 {
+		t.Fatalf("Expected the user to be created: %v %+v\n", err, user)
+	}
+
+	existing := User{Name: "Someone else", Email: "joe@example.com"}
+
+	if created, err := query.FirstOrCreate(&existing); err != nil || created || existing.ID != user.ID || existing.Name != "Joe" {
+		t.Fatalf("Expected the existing user to be found: %v %+v\n", err, existing)
+	}
+
+	// A record inserted between the lookup and the insert is read back instead of failing.
+	racing := RacingUser{Name: "Jen", Email: "jen@example.com", db: db}
+
+	if created, err := jdb.From("user").Where("email = ?", "jen@example.com").FirstOrCreate(&racing); err != nil || created || racing.Name != "Winner" || racing.inserted {
+		t.Fatalf("Expected the conflicting insert to be skipped: %v %+v\n", err, racing)
+	}
+
+	if exists, err := jdb.From("user").Where("email = ?", "joe@example.com").Exists(); err != nil || !exists {
+		t.Fatalf("Expected the user to exist: %v\n", err)
+	}
+
+	type Counter struct {
+		ID uint `db:"id"`
+	}
+
+	db.Exec("CREATE TABLE counter (id integer primary key)")
+
+	// Every column is skipped so the insert uses DEFAULT VALUES, which can't have a conflict clause in SQLite.
+	if created, err := jdb.From("counter").FirstOrCreate(&Counter{}); err != nil || !created {
+		t.Fatalf("Expected the counter to be created: %v\n", err)
+	}
+}
@@ -0,0 +1,12 @@
+package sqlj
+
+// Implemented by the methods cmd/sqlj-gen generates for a struct.
+// When a struct pointer implements this sqlj uses the methods in place of
+// reflection to list the columns, scan rows and read values.
+// The methods must list the db tagged fields in the order they are declared,
+// which the generator does as long as it is re-run whenever the struct changes.
+type GeneratedModel interface {
+	SqljColumns() []string
+	SqljScanTargets() []any
+	SqljValues() []any
+}
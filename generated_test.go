@@ -0,0 +1,67 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// A struct without db tags so it can only be used through the generated methods.
+type GeneratedUser struct {
+	ID    uint
+	Name  string
+	Email string
+}
+
+func (r *GeneratedUser) SqljColumns() []string {
+	return []string{"id", "name", "email"}
+}
+
+func (r *GeneratedUser) SqljScanTargets() []any {
+	return []any{&r.ID, &r.Name, &r.Email}
+}
+
+func (r *GeneratedUser) SqljValues() []any {
+	return []any{r.ID, r.Name, r.Email}
+}
+
+func TestGeneratedModel(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	user := GeneratedUser{Name: "Joe", Email: "joe@example.com"}
+
+	if err := jdb.Insert("user", &user); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	if user.ID == 0 {
+		t.Fatal("Expected the inserted ID to be scanned into the struct")
+	}
+
+	var found GeneratedUser
+	if err := jdb.Get("user", user.ID, &found); err != nil {
+		t.Fatalf("Failed to get user: %s\n", err.Error())
+	}
+
+	if found != user {
+		t.Fatalf("Expected %+v, got: %+v\n", user, found)
+	}
+
+	var all []GeneratedUser
+	if err := jdb.From("user").Order("id", "ASC").All(&all); err != nil {
+		t.Fatalf("Failed to select users: %s\n", err.Error())
+	}
+
+	if len(all) != 1 || all[0] != user {
+		t.Fatalf("Unexpected users: %+v\n", all)
+	}
+}
@@ -2,9 +2,8 @@ module github.com/JoeAxon/sqlj
 
 go 1.23.0
 
-require github.com/mattn/go-sqlite3 v1.14.24
-
 require (
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.24
 )
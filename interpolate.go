@@ -0,0 +1,90 @@
+package sqlj
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Substitutes the args into the ? and $n placeholders of sql as literals quoted for the dialect.
+// This is intended for logging and debugging only. The result should never be executed.
+// Placeholders without a matching argument are left as they are.
+func Interpolate(sql string, args []any, dialect Dialect) string {
+	matches := scanPlaceholders(sql)
+
+	var b strings.Builder
+	last := 0
+
+	for _, m := range matches {
+		if m.Index < 1 || m.Index > len(args) {
+			continue
+		}
+
+		b.WriteString(sql[last:m.Start])
+		b.WriteString(formatLiteral(args[m.Index-1], dialect))
+		last = m.End
+	}
+
+	b.WriteString(sql[last:])
+
+	return b.String()
+}
+
+// Formats a value as an SQL literal for the given dialect.
+func formatLiteral(arg any, dialect Dialect) string {
+	value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+	if err != nil {
+		return quoteString(fmt.Sprint(arg))
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		if dialect == SQLiteDialect {
+			if v {
+				return "1"
+			}
+
+			return "0"
+		}
+
+		if v {
+			return "TRUE"
+		}
+
+		return "FALSE"
+	case []byte:
+		if dialect == PostgresDialect {
+			return strings.Join([]string{"'\\x", hex.EncodeToString(v), "'"}, "")
+		}
+
+		return strings.Join([]string{"X'", hex.EncodeToString(v), "'"}, "")
+	case time.Time:
+		switch dialect {
+		case PostgresDialect:
+			return quoteString(v.Format("2006-01-02 15:04:05.999999-07:00"))
+		case SQLiteDialect:
+			// This matches the format the sqlite3 driver stores times in.
+			return quoteString(v.Format("2006-01-02 15:04:05.999999999-07:00"))
+		}
+
+		return quoteString(v.Format(time.RFC3339Nano))
+	case string:
+		return quoteString(v)
+	}
+
+	return quoteString(fmt.Sprint(value))
+}
+
+// Quotes a string literal by doubling up any single quotes.
+func quoteString(s string) string {
+	return strings.Join([]string{"'", strings.ReplaceAll(s, "'", "''"), "'"}, "")
+}
@@ -0,0 +1,62 @@
+package sqlj
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScanPlaceholders(t *testing.T) {
+	result := scanPlaceholders("a = $1 AND b = '$2' AND c = ? AND d = $10")
+
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 matches, got: %d\n", len(result))
+	}
+
+	if result[0].Index != 1 || result[1].Index != 1 || result[2].Index != 10 {
+		t.Fatalf("Unexpected placeholder indexes: %v\n", result)
+	}
+
+	if result[2].Start != 38 || result[2].End != 41 {
+		t.Fatalf("Unexpected placeholder position: %v\n", result[2])
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	name := "O'Brien"
+	var assignee *uint
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	args := []any{&name, 42, 1.5, true, assignee, createdAt, []byte{0xde, 0xad}}
+	sql := "SELECT * FROM user WHERE name = $1 AND age = $2 AND score = $3 AND active = $4 AND assigned_to = $5 AND created_at = $6 AND data = $7 AND note = '$1'"
+
+	result := Interpolate(sql, args, PostgresDialect)
+	expected := "SELECT * FROM user WHERE name = 'O''Brien' AND age = 42 AND score = 1.5 AND active = TRUE AND assigned_to = NULL AND created_at = '2024-03-01 12:30:00+00:00' AND data = '\\xdead' AND note = '$1'"
+
+	if result != expected {
+		t.Fatalf("Unexpected Postgres interpolation:\n%s\n", result)
+	}
+
+	result = Interpolate(sql, args, SQLiteDialect)
+	expected = "SELECT * FROM user WHERE name = 'O''Brien' AND age = 42 AND score = 1.5 AND active = 1 AND assigned_to = NULL AND created_at = '2024-03-01 12:30:00+00:00' AND data = X'dead' AND note = '$1'"
+
+	if result != expected {
+		t.Fatalf("Unexpected SQLite interpolation:\n%s\n", result)
+	}
+
+	result = Interpolate("a = ? AND b = ?", []any{"x"}, "")
+
+	if result != "a = 'x' AND b = ?" {
+		t.Fatalf("Unexpected interpolation with missing args: %s\n", result)
+	}
+}
+
+func TestDebugString(t *testing.T) {
+	jdb := NewDB(nil)
+	jdb.Dialect = PostgresDialect
+
+	result := jdb.From("user").Where("name = ?", "Joe").Limit(5).DebugString()
+
+	if result != "SELECT * FROM user WHERE name = 'Joe' LIMIT 5" {
+		t.Fatalf("Unexpected debug string: %s\n", result)
+	}
+}
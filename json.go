@@ -0,0 +1,58 @@
+package sqlj
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Stores the value v points to as JSON, e.g. as a query argument: sqlj.JSON(&settings).
+// Fields with the json tag option are wrapped automatically.
+// This works with text columns, such as in SQLite, and with the json and jsonb types in PostgreSQL.
+type JSONValue struct {
+	ptr any
+}
+
+// Wraps a pointer to a value so it is marshalled to JSON when written and unmarshalled when read.
+// A nil pointer, map or slice is written as NULL and NULL is read as the zero value.
+func JSON(v any) *JSONValue {
+	return &JSONValue{ptr: v}
+}
+
+func (j JSONValue) Value() (driver.Value, error) {
+	value := reflect.ValueOf(j.ptr).Elem()
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+	}
+
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	// Written as a string, rather than bytes, so PostgreSQL infers the column type instead of bytea.
+	return string(data), nil
+}
+
+func (j *JSONValue) Scan(src any) error {
+	value := reflect.ValueOf(j.ptr).Elem()
+
+	// Reset the value first so a map or slice isn't shared with, or merged into, a previous row.
+	value.SetZero()
+
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, j.ptr)
+	case string:
+		return json.Unmarshal([]byte(data), j.ptr)
+	}
+
+	return fmt.Errorf("Cannot scan %T into a JSON value", src)
+}
@@ -0,0 +1,100 @@
+package sqlj
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type Settings struct {
+	Theme  string   `json:"theme"`
+	Alerts []string `json:"alerts"`
+}
+
+type Profile struct {
+	ID       uint              `db:"id"`
+	Settings Settings          `db:"settings,json"`
+	Extra    *Settings         `db:"extra,json"`
+	Labels   map[string]string `db:"labels,json"`
+}
+
+func checkJSONFields(t *testing.T, jdb DB, table string) {
+	profile := Profile{
+		Settings: Settings{Theme: "dark", Alerts: []string{"email"}},
+		Labels:   map[string]string{"team": "core"},
+	}
+
+	if err := jdb.Insert(table, &profile); err != nil {
+		t.Fatalf("Failed to insert profile: %s\n", err.Error())
+	}
+
+	if profile.Extra != nil || profile.Settings.Theme != "dark" || profile.Labels["team"] != "core" {
+		t.Fatalf("Unexpected profile after insert: %+v\n", profile)
+	}
+
+	profile.Extra = &Settings{Theme: "light"}
+	profile.Labels = nil
+
+	if err := jdb.Update(table, profile.ID, &profile); err != nil {
+		t.Fatalf("Failed to update profile: %s\n", err.Error())
+	}
+
+	var found Profile
+
+	if err := jdb.Get(table, profile.ID, &found); err != nil {
+		t.Fatalf("Failed to retrieve profile: %s\n", err.Error())
+	}
+
+	if found.Extra == nil || found.Extra.Theme != "light" || found.Labels != nil || !reflect.DeepEqual(found.Settings, profile.Settings) {
+		t.Fatalf("Unexpected profile: %+v\n", found)
+	}
+
+	if err := jdb.Insert(table, &Profile{Labels: map[string]string{"team": "web"}}); err != nil {
+		t.Fatalf("Failed to insert profile: %s\n", err.Error())
+	}
+
+	var profiles []Profile
+
+	if err := jdb.From(table).Order("id", "ASC").All(&profiles); err != nil {
+		t.Fatalf("Failed to select profiles: %s\n", err.Error())
+	}
+
+	// Each row must get its own map rather than sharing the one scanned into first.
+	if len(profiles) != 2 || profiles[0].Labels != nil || !reflect.DeepEqual(profiles[1].Labels, map[string]string{"team": "web"}) {
+		t.Fatalf("Unexpected profiles: %+v\n", profiles)
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE profile (id integer primary key, settings text not null, extra text, labels text)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	checkJSONFields(t, NewDB(db), "profile")
+
+	var settings Settings
+
+	if err := JSON(&settings).Scan(42); err == nil {
+		t.Fatal("Expected an error scanning a number into a JSON value")
+	}
+}
+
+func TestJSONFieldsPostgres(t *testing.T) {
+	if _, err := pgDB.Exec("CREATE TABLE profiles (id serial primary key, settings jsonb not null, extra jsonb, labels json)"); err != nil {
+		t.Fatalf("Failed to set-up postgres DB: %s\n", err.Error())
+	}
+
+	t.Cleanup(func() {
+		if _, err := pgDB.Exec("DROP TABLE profiles"); err != nil {
+			t.Logf("Cleanup - Failed to remove pg profiles table: %s\n", err.Error())
+		}
+	})
+
+	checkJSONFields(t, NewDB(pgDB), "profiles")
+}
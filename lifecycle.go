@@ -0,0 +1,113 @@
+package sqlj
+
+import (
+	"context"
+	"reflect"
+)
+
+// The lifecycle hooks below are optional interfaces a struct can implement to run code around
+// the queries sqlj makes with it, e.g. to set timestamps, normalise values or validate.
+// They should be implemented on the pointer receiver.
+// An error returned from a Before hook aborts the operation before any query is made.
+// An error returned from an After hook is returned once the query has completed,
+// so run the operation in a transaction if the change should be rolled back.
+
+// Called by .Insert and .InsertWithFields before the row is inserted.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// Called by .Insert and .InsertWithFields once the new row has been marshalled into the struct.
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// Called by .Update, .UpdateWithFields, .Patch and .UpdateColumns before the row is updated.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// Called by .Update, .UpdateWithFields, .Patch and .UpdateColumns once the updated row has been marshalled into the struct.
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// Called by .DeleteValue before the row is deleted.
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// Called by .DeleteValue once the row has been deleted.
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// Called on each struct a query reads a row into, e.g. by .Get, .Select and the fluent API.
+type AfterFinder interface {
+	AfterFind(ctx context.Context) error
+}
+
+// Calls the Before hook for the operation if v implements it.
+func (jdb *DB) beforeHook(operation Operation, v any) error {
+	ctx := jdb.context()
+
+	switch operation {
+	case InsertOperation:
+		if h, ok := v.(BeforeInserter); ok {
+			return h.BeforeInsert(ctx)
+		}
+	case UpdateOperation:
+		if h, ok := v.(BeforeUpdater); ok {
+			return h.BeforeUpdate(ctx)
+		}
+	case DeleteOperation:
+		if h, ok := v.(BeforeDeleter); ok {
+			return h.BeforeDelete(ctx)
+		}
+	}
+
+	return nil
+}
+
+// Calls the After hook for the operation if v implements it.
+func (jdb *DB) afterHook(operation Operation, v any) error {
+	ctx := jdb.context()
+
+	switch operation {
+	case InsertOperation:
+		if h, ok := v.(AfterInserter); ok {
+			return h.AfterInsert(ctx)
+		}
+	case UpdateOperation:
+		if h, ok := v.(AfterUpdater); ok {
+			return h.AfterUpdate(ctx)
+		}
+	case DeleteOperation:
+		if h, ok := v.(AfterDeleter); ok {
+			return h.AfterDelete(ctx)
+		}
+	case GetOperation, SelectOperation:
+		if h, ok := v.(AfterFinder); ok {
+			return h.AfterFind(ctx)
+		}
+	}
+
+	return nil
+}
+
+// Calls AfterFind on the last n elements of the slice v points to, i.e. those just scanned.
+func (jdb *DB) afterFindAll(v any, n int64) error {
+	slice := reflect.ValueOf(v).Elem()
+
+	if !reflect.PointerTo(slice.Type().Elem()).Implements(reflect.TypeFor[AfterFinder]()) {
+		return nil
+	}
+
+	for idx := slice.Len() - int(n); idx < slice.Len(); idx++ {
+		if err := jdb.afterHook(SelectOperation, slice.Index(idx).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package sqlj
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type HookedUser struct {
+	ID    uint   `db:"id"`
+	Name  string `db:"name"`
+	Email string `db:"email"`
+
+	calls []string
+	found bool
+}
+
+func (u *HookedUser) BeforeInsert(ctx context.Context) error {
+	u.calls = append(u.calls, "BeforeInsert")
+	u.Email = strings.ToLower(u.Email)
+
+	if u.Name == "" {
+		return errors.New("Name is required")
+	}
+
+	return nil
+}
+
+func (u *HookedUser) AfterInsert(ctx context.Context) error {
+	u.calls = append(u.calls, "AfterInsert")
+	return nil
+}
+
+func (u *HookedUser) BeforeUpdate(ctx context.Context) error {
+	u.calls = append(u.calls, "BeforeUpdate")
+	u.Email = strings.ToLower(u.Email)
+	return nil
+}
+
+func (u *HookedUser) AfterUpdate(ctx context.Context) error {
+	u.calls = append(u.calls, "AfterUpdate")
+	return nil
+}
+
+func (u *HookedUser) BeforeDelete(ctx context.Context) error {
+	u.calls = append(u.calls, "BeforeDelete")
+	return nil
+}
+
+func (u *HookedUser) AfterDelete(ctx context.Context) error {
+	u.calls = append(u.calls, "AfterDelete")
+	return nil
+}
+
+func (u *HookedUser) AfterFind(ctx context.Context) error {
+	u.found = true
+	return nil
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	hook := &recordingHook{}
+
+	jdb := NewDB(db)
+	jdb.Hook = hook
+
+	invalid := HookedUser{Email: "nobody@example.com"}
+
+	if err := jdb.Insert("user", &invalid); err == nil || err.Error() != "Name is required" {
+		t.Fatalf("Expected the BeforeInsert error, got: %v\n", err)
+	}
+
+	if len(hook.before) != 0 {
+		t.Fatal("Expected the BeforeInsert error to abort the insert")
+	}
+
+	user := HookedUser{Name: "Joe", Email: "Joe@Example.com"}
+
+	if err := jdb.Insert("user", &user); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	if user.Email != "joe@example.com" || user.found {
+		t.Fatalf("Unexpected user after insert: %+v\n", user)
+	}
+
+	user.Email = "JOE@EXAMPLE.ORG"
+
+	if err := jdb.Patch("user", user.ID, &user); err != nil {
+		t.Fatalf("Failed to patch user: %s\n", err.Error())
+	}
+
+	if user.Email != "joe@example.org" {
+		t.Fatalf("Expected BeforeUpdate to run before the update is built: %+v\n", user)
+	}
+
+	if err := jdb.DeleteValue("user", user.ID, &user); err != nil {
+		t.Fatalf("Failed to delete user: %s\n", err.Error())
+	}
+
+	expected := "BeforeInsert AfterInsert BeforeUpdate AfterUpdate BeforeDelete AfterDelete"
+
+	if strings.Join(user.calls, " ") != expected {
+		t.Fatalf("Unexpected hook calls: %v\n", user.calls)
+	}
+
+	jdb.Insert("user", &HookedUser{Name: "Jen"})
+	jdb.Insert("user", &HookedUser{Name: "Jon"})
+
+	var found HookedUser
+
+	if err := jdb.From("user").Where("name = ?", "Jen").One(&found); err != nil {
+		t.Fatalf("Failed to retrieve user: %s\n", err.Error())
+	}
+
+	if !found.found {
+		t.Fatal("Expected AfterFind to be called by .One")
+	}
+
+	users := []HookedUser{{Name: "Existing"}}
+
+	if err := jdb.Select("user", &users); err != nil {
+		t.Fatalf("Failed to select users: %s\n", err.Error())
+	}
+
+	if len(users) != 3 || users[0].found || !users[1].found || !users[2].found {
+		t.Fatalf("Expected AfterFind on each selected user: %+v\n", users)
+	}
+}
@@ -0,0 +1,28 @@
+package sqlj
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Returned by the update methods when v has a field with the version tag option
+// and the row no longer has the version held by v, i.e. it has been changed or deleted since v was read.
+var ErrStaleObject = errors.New("Stale object: the row has been changed or deleted")
+
+// Returns the optimistic locking condition for an update of v and the value for its placeholder.
+func versionWhere(v any) ([]WhereClause, []any) {
+	value := reflect.ValueOf(v).Elem()
+	meta := getStructMeta(value.Type())
+
+	if meta.version == "" {
+		return nil, nil
+	}
+
+	version := value.FieldByIndex(meta.fields[meta.byName[meta.version]].index).Interface()
+
+	return []WhereClause{{AND_TYPE, SimpleExpr{columnEq(meta.version)}}}, []any{version}
+}
+
+func hasVersion(v any) bool {
+	return getStructMeta(reflect.TypeOf(v).Elem()).version != ""
+}
@@ -0,0 +1,70 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type Document struct {
+	ID      uint   `db:"id"`
+	Title   string `db:"title"`
+	Version int    `db:"version,version"`
+}
+
+func TestOptimisticLocking(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE document (id integer primary key, title text, version integer not null default 0)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	doc := Document{Title: "Draft", Version: 1}
+
+	if err := jdb.Insert("document", &doc); err != nil {
+		t.Fatalf("Failed to insert document: %s\n", err.Error())
+	}
+
+	query, values, _ := jdb.BuildUpdate("document", doc.ID, &doc)
+
+	if query != "UPDATE document SET title = $1, version = version + 1 WHERE id = $2 AND version = $3 RETURNING id, title, version" {
+		t.Fatalf("Unexpected update SQL: %s\n", query)
+	}
+
+	if len(values) != 3 || values[2] != 1 {
+		t.Fatalf("Unexpected update values: %v\n", values)
+	}
+
+	stale := doc
+
+	doc.Title = "Final"
+
+	if err := jdb.Update("document", doc.ID, &doc); err != nil {
+		t.Fatalf("Failed to update document: %s\n", err.Error())
+	}
+
+	if doc.Version != 2 {
+		t.Fatalf("Expected the version to be incremented, got: %d\n", doc.Version)
+	}
+
+	stale.Title = "Overwritten"
+
+	if err := jdb.Update("document", stale.ID, &stale); err != ErrStaleObject {
+		t.Fatalf("Expected ErrStaleObject, got: %v\n", err)
+	}
+
+	if err := jdb.UpdateColumns("document", doc.ID, &doc, "title"); err != nil || doc.Version != 3 {
+		t.Fatalf("Expected .UpdateColumns to increment the version: %v %+v\n", err, doc)
+	}
+
+	var found Document
+
+	if err := jdb.Get("document", doc.ID, &found); err != nil || found.Title != "Final" || found.Version != 3 {
+		t.Fatalf("Expected the stale update to be rejected: %v %+v\n", err, found)
+	}
+}
@@ -0,0 +1,198 @@
+package sqlj
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Describes the db tagged fields of a struct type.
+// This is cached per type so the fields and tags are only walked once.
+type structMeta struct {
+	fields  []fieldMeta
+	columns []string
+	byName  map[string]int // Column -> index into fields
+
+	// Set when a field has an option that makes the insert or update SQL depend on its value.
+	valueDependent bool
+
+	softDelete string // The column of the field with the softdelete option, if any
+	version    string // The column of the field with the version option, if any
+}
+
+type fieldMeta struct {
+	column  string
+	index   []int
+	options []string // Anything following the column name in the tag, e.g. db:"name,opt"
+}
+
+// The tag options that control how a field is written.
+const (
+	readonlyOption  = "readonly"  // Never written, only read
+	insertOption    = "insert"    // Written on insert but not on update
+	omitemptyOption = "omitempty" // Not written when the field holds its zero value
+	defaultOption   = "default="  // A literal written on insert when the field holds its zero value
+
+	autoCreateTimeOption = "autoCreateTime" // Set to the current time on insert when the field holds its zero value
+	autoUpdateTimeOption = "autoUpdateTime" // Set to the current time on every insert and update
+
+	softDeleteOption = "softdelete" // Holds the time the row was deleted, see .Delete
+	versionOption    = "version"    // Incremented by every update for optimistic locking, see ErrStaleObject
+	jsonOption       = "json"       // Stored as JSON, see JSONValue
+)
+
+func (f fieldMeta) hasOption(option string) bool {
+	return slices.Contains(f.options, option)
+}
+
+// Returns the literal given by a default= option.
+func (f fieldMeta) defaultValue() (string, bool) {
+	for _, o := range f.options {
+		if value, ok := strings.CutPrefix(o, defaultOption); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// Returns a pointer to the field of the struct value v, wrapped by JSON for the json tag option.
+// This can be scanned into and used as a query argument.
+func (f fieldMeta) pointer(v reflect.Value) any {
+	pointer := v.FieldByIndex(f.index).Addr().Interface()
+
+	if f.hasOption(jsonOption) {
+		return JSON(pointer)
+	}
+
+	return pointer
+}
+
+// Reports whether the field has any option that controls how it is written.
+// These fields are not subject to the DB's SkipOnInsert list.
+func (f fieldMeta) hasWriteOptions() bool {
+	_, hasDefault := f.defaultValue()
+
+	return hasDefault || slices.ContainsFunc(f.options, func(o string) bool {
+		switch o {
+		case readonlyOption, insertOption, omitemptyOption, autoCreateTimeOption, autoUpdateTimeOption, versionOption:
+			return true
+		}
+
+		return false
+	})
+}
+
+var structMetas sync.Map // reflect.Type -> *structMeta
+
+// Returns the metadata for the struct type t.
+func getStructMeta(t reflect.Type) *structMeta {
+	if meta, ok := structMetas.Load(t); ok {
+		return meta.(*structMeta)
+	}
+
+	meta := &structMeta{byName: make(map[string]int)}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column, options := parseTag(f.Tag.Get("db"))
+
+		if column == "" || column == "-" {
+			continue
+		}
+
+		fm := fieldMeta{
+			column:  column,
+			index:   f.Index,
+			options: options,
+		}
+
+		if _, ok := fm.defaultValue(); ok || fm.hasOption(omitemptyOption) {
+			meta.valueDependent = true
+		}
+
+		if fm.hasOption(softDeleteOption) {
+			meta.softDelete = column
+		}
+
+		if fm.hasOption(versionOption) {
+			meta.version = column
+		}
+
+		meta.byName[column] = len(meta.fields)
+		meta.fields = append(meta.fields, fm)
+		meta.columns = append(meta.columns, column)
+	}
+
+	actual, _ := structMetas.LoadOrStore(t, meta)
+
+	return actual.(*structMeta)
+}
+
+// Returns the columns for the struct type t.
+// These come from SqljColumns when *t is a GeneratedModel, otherwise from the struct metadata.
+func structColumns(t reflect.Type) []string {
+	if model, ok := reflect.New(t).Interface().(GeneratedModel); ok {
+		return model.SqljColumns()
+	}
+
+	return getStructMeta(t).columns
+}
+
+// Splits a db tag into the column name and its options.
+// A default= option takes the rest of the tag so the literal may contain commas.
+func parseTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+
+	for idx := 0; idx < len(parts); idx++ {
+		if idx > 0 && strings.HasPrefix(strings.TrimSpace(parts[idx]), defaultOption) {
+			parts = append(parts[:idx], strings.Join(parts[idx:], ","))
+		}
+
+		parts[idx] = strings.TrimSpace(parts[idx])
+	}
+
+	return parts[0], parts[1:]
+}
+
+// Returns pointers to each db field of the struct value v, in the order of the metadata.
+// v must be addressable.
+func (m *structMeta) pointers(v reflect.Value) []any {
+	pointers := make([]any, len(m.fields))
+
+	for idx, f := range m.fields {
+		pointers[idx] = f.pointer(v)
+	}
+
+	return pointers
+}
+
+// Identifies SQL generated purely from a struct type, table and the DB configuration.
+type sqlCacheKey struct {
+	typ       reflect.Type
+	table     string
+	operation Operation
+	config    string
+}
+
+var sqlCache sync.Map // sqlCacheKey -> string
+
+// Returns the cached SQL for the key, calling build and caching the result if there isn't any.
+func cachedSQL(key sqlCacheKey, build func() string) string {
+	if sql, ok := sqlCache.Load(key); ok {
+		return sql.(string)
+	}
+
+	sql := build()
+	sqlCache.Store(key, sql)
+
+	return sql
+}
+
+func (jdb *DB) sqlCacheKey(t reflect.Type, table string, operation Operation) sqlCacheKey {
+	// The ID column, soft delete column and skipped columns are the only configuration that affects the generated SQL.
+	config := strings.Join(append([]string{jdb.getIDName(), jdb.softDeleteColumn(table, t)}, jdb.SkipOnInsert...), "\x00")
+
+	return sqlCacheKey{t, table, operation, config}
+}
@@ -0,0 +1,160 @@
+package sqlj
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type TaggedRecord struct {
+	ID       uint   `db:"id"`
+	Name     string `db:"name, readonly"`
+	Ignored  string `db:"-"`
+	Untagged string
+	Email    string `db:"email,insert,omitempty"`
+}
+
+func TestStructMeta(t *testing.T) {
+	var wg sync.WaitGroup
+	metas := make([]*structMeta, 10)
+
+	for i := range metas {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			metas[i] = getStructMeta(reflect.TypeOf(TaggedRecord{}))
+		}()
+	}
+
+	wg.Wait()
+
+	meta := metas[0]
+
+	for _, m := range metas {
+		if m != meta {
+			t.Fatal("Expected every caller to share the cached metadata")
+		}
+	}
+
+	if !reflect.DeepEqual(meta.columns, []string{"id", "name", "email"}) {
+		t.Fatalf("Unexpected columns: %v\n", meta.columns)
+	}
+
+	if !meta.fields[1].hasOption("readonly") || meta.fields[1].hasOption("insert") {
+		t.Fatalf("Unexpected name options: %v\n", meta.fields[1].options)
+	}
+
+	if !reflect.DeepEqual(meta.fields[2].options, []string{"insert", "omitempty"}) || !reflect.DeepEqual(meta.fields[2].index, []int{4}) {
+		t.Fatalf("Unexpected email metadata: %+v\n", meta.fields[2])
+	}
+
+	if !meta.valueDependent {
+		t.Fatal("Expected omitempty to make the SQL value dependent")
+	}
+
+	column, options := parseTag("created_at, insert, default=coalesce(now(), 0)")
+
+	if column != "created_at" || !reflect.DeepEqual(options, []string{"insert", "default=coalesce(now(), 0)"}) {
+		t.Fatalf("Unexpected tag parts: %s %v\n", column, options)
+	}
+}
+
+func TestSQLCache(t *testing.T) {
+	jdb := NewDB(nil)
+	user := User{}
+
+	first, _, _ := jdb.BuildInsert("cached_user", &user)
+	second, _, _ := jdb.BuildInsert("cached_user", &user)
+
+	if first != second {
+		t.Fatalf("Expected the same SQL, got: %s and %s\n", first, second)
+	}
+
+	jdb.SkipOnInsert = []string{"id", "email"}
+
+	third, _, _ := jdb.BuildInsert("cached_user", &user)
+
+	if third != "INSERT INTO cached_user (name, created_at) VALUES ($1, $2) RETURNING id, name, email, created_at" {
+		t.Fatalf("Expected the SQL to reflect the DB configuration, got: %s\n", third)
+	}
+}
+
+func benchmarkDB(b *testing.B, rows int) DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		b.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	b.Cleanup(func() { db.Close() })
+
+	db.SetMaxOpenConns(1)
+	db.Exec("CREATE TABLE employee (id integer primary key, first_name text, last_name text, email text, location text, age integer, user_id integer)")
+
+	db.Exec(fmt.Sprintf(`
+    WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < %d)
+    INSERT INTO employee (first_name, last_name, email, location, age)
+    SELECT 'Joe', 'Smith', 'joe' || i || '@example.com', 'England', 21 FROM n
+  `, rows))
+
+	return NewDB(db)
+}
+
+func BenchmarkSelectAll(b *testing.B) {
+	const rows = 1000
+
+	jdb := benchmarkDB(b, rows)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var employees []Employee
+
+		if err := jdb.Select("employee", &employees); err != nil {
+			b.Fatalf("Failed to select employees: %s\n", err.Error())
+		}
+	}
+
+	b.StopTimer()
+
+	// Divide the allocations by the number of rows to make the per-row cost visible.
+	allocs := testing.AllocsPerRun(10, func() {
+		var employees []Employee
+		jdb.Select("employee", &employees)
+	})
+
+	b.ReportMetric(allocs/rows, "allocs/row")
+}
+
+func BenchmarkGet(b *testing.B) {
+	jdb := benchmarkDB(b, 1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var employee Employee
+
+		if err := jdb.Get("employee", 1, &employee); err != nil {
+			b.Fatalf("Failed to get employee: %s\n", err.Error())
+		}
+	}
+}
+
+func BenchmarkBuildInsert(b *testing.B) {
+	jdb := NewDB(nil)
+	employee := Employee{FirstName: "Joe", LastName: "Smith", Email: "joe@example.com"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, _, err := jdb.BuildInsert("employee", &employee); err != nil {
+			b.Fatalf("Failed to build insert: %s\n", err.Error())
+		}
+	}
+}
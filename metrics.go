@@ -0,0 +1,148 @@
+package sqlj
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"expvar"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Receives a measurement for every query executed by a DB.
+// Implement this to send metrics to your own monitoring system.
+type Metrics interface {
+	RecordQuery(operation Operation, table string, duration time.Duration, err error)
+}
+
+// The default upper bounds of the latency histogram buckets used by NewMetricsCollector.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// A Metrics implementation that keeps counters and latency histograms per operation and table in memory.
+// sql.ErrNoRows is not counted as an error as it usually means a record wasn't found.
+type MetricsCollector struct {
+	mu      sync.Mutex
+	buckets []time.Duration
+	series  map[metricsKey]*OperationMetrics
+}
+
+type metricsKey struct {
+	operation Operation
+	table     string
+}
+
+// The metrics recorded for an operation on a table.
+// The Buckets are cumulative, each one counts the queries that took at most UpperBound.
+// Queries slower than the last bucket are only included in Count.
+type OperationMetrics struct {
+	Operation     Operation
+	Table         string
+	Count         uint64
+	Errors        uint64
+	TotalDuration time.Duration
+	Buckets       []LatencyBucket
+}
+
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// Creates a MetricsCollector with the given histogram bucket upper bounds.
+// DefaultLatencyBuckets are used if none are given.
+func NewMetricsCollector(buckets ...time.Duration) *MetricsCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &MetricsCollector{
+		buckets: buckets,
+		series:  map[metricsKey]*OperationMetrics{},
+	}
+}
+
+func (c *MetricsCollector) RecordQuery(operation Operation, table string, duration time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := metricsKey{operation, table}
+
+	series, ok := c.series[key]
+	if !ok {
+		series = &OperationMetrics{
+			Operation: operation,
+			Table:     table,
+			Buckets:   make([]LatencyBucket, len(c.buckets)),
+		}
+
+		for idx, bound := range c.buckets {
+			series.Buckets[idx].UpperBound = bound
+		}
+
+		c.series[key] = series
+	}
+
+	series.Count++
+	series.TotalDuration += duration
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		series.Errors++
+	}
+
+	for idx := range series.Buckets {
+		if duration <= series.Buckets[idx].UpperBound {
+			series.Buckets[idx].Count++
+		}
+	}
+}
+
+// Returns a copy of the metrics recorded so far ordered by table and operation.
+func (c *MetricsCollector) Snapshot() []OperationMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make([]OperationMetrics, 0, len(c.series))
+
+	for _, series := range c.series {
+		copied := *series
+		copied.Buckets = slices.Clone(series.Buckets)
+
+		snapshot = append(snapshot, copied)
+	}
+
+	slices.SortFunc(snapshot, func(a, b OperationMetrics) int {
+		return cmp.Or(cmp.Compare(a.Table, b.Table), cmp.Compare(a.Operation, b.Operation))
+	})
+
+	return snapshot
+}
+
+// Removes the metrics recorded so far.
+func (c *MetricsCollector) Reset() {
+	c.mu.Lock()
+	c.series = map[metricsKey]*OperationMetrics{}
+	c.mu.Unlock()
+}
+
+// Publishes the snapshot as an expvar variable with the given name so it is served on /debug/vars.
+// Like expvar.Publish this panics if the name is already in use.
+func (c *MetricsCollector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Snapshot()
+	}))
+}
@@ -0,0 +1,108 @@
+package sqlj
+
+import (
+	"database/sql"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Counts the collectors published by the tests, as expvar names can't be reused.
+var publishCount int
+
+func TestMetricsCollector(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	collector := NewMetricsCollector()
+
+	jdb := NewDB(db)
+	jdb.Metrics = collector
+
+	user := User{Name: "Joe", Email: "joe@example.com"}
+
+	if err := jdb.Insert("user", &user); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	var found User
+	if err := jdb.From("user").Get(user.ID, &found); err != nil {
+		t.Fatalf("Failed to get user: %s\n", err.Error())
+	}
+
+	if err := jdb.From("user").Get(100, &found); err != sql.ErrNoRows {
+		t.Fatalf("Expected sql.ErrNoRows, got: %v\n", err)
+	}
+
+	if _, err := jdb.From("missing").Count(); err == nil {
+		t.Fatal("Expected an error counting a missing table")
+	}
+
+	snapshot := collector.Snapshot()
+
+	if len(snapshot) != 3 {
+		t.Fatalf("Expected 3 series, got: %d\n", len(snapshot))
+	}
+
+	missing, get, insert := snapshot[0], snapshot[1], snapshot[2]
+
+	if missing.Table != "missing" || missing.Operation != CountOperation || missing.Errors != 1 {
+		t.Fatalf("Unexpected count metrics: %+v\n", missing)
+	}
+
+	if get.Operation != GetOperation || get.Count != 2 || get.Errors != 0 {
+		t.Fatalf("Unexpected get metrics: %+v\n", get)
+	}
+
+	if insert.Operation != InsertOperation || insert.Count != 1 || len(insert.Buckets) != len(DefaultLatencyBuckets) {
+		t.Fatalf("Unexpected insert metrics: %+v\n", insert)
+	}
+
+	// Each run, e.g. with -count, publishes under its own name.
+	publishCount++
+	name := fmt.Sprintf("sqlj_%s_%d", t.Name(), publishCount)
+
+	collector.Publish(name)
+
+	var published []OperationMetrics
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &published); err != nil {
+		t.Fatalf("Failed to decode published metrics: %s\n", err.Error())
+	}
+
+	if len(published) != 3 {
+		t.Fatalf("Expected 3 published series, got: %d\n", len(published))
+	}
+}
+
+func TestLatencyBuckets(t *testing.T) {
+	collector := NewMetricsCollector(10*time.Millisecond, time.Millisecond)
+
+	collector.RecordQuery(SelectOperation, "user", 500*time.Microsecond, nil)
+	collector.RecordQuery(SelectOperation, "user", 5*time.Millisecond, nil)
+	collector.RecordQuery(SelectOperation, "user", time.Second, nil)
+
+	buckets := collector.Snapshot()[0].Buckets
+
+	if buckets[0].UpperBound != time.Millisecond || buckets[0].Count != 1 {
+		t.Fatalf("Unexpected first bucket: %+v\n", buckets[0])
+	}
+
+	if buckets[1].UpperBound != 10*time.Millisecond || buckets[1].Count != 2 {
+		t.Fatalf("Unexpected second bucket: %+v\n", buckets[1])
+	}
+
+	collector.Reset()
+
+	if len(collector.Snapshot()) != 0 {
+		t.Fatal("Expected no metrics after reset")
+	}
+}
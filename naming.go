@@ -0,0 +1,158 @@
+package sqlj
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Allows a struct to specify its table for the methods that infer it, such as .Save and .Find.
+type Tabler interface {
+	TableName() string
+}
+
+// The default naming strategy, e.g. User becomes users and HTTPRequest becomes http_requests.
+func SnakeCasePlural(name string) string {
+	return pluralize(snakeCase(name))
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var sb strings.Builder
+
+	for idx, r := range runes {
+		if idx > 0 && unicode.IsUpper(r) {
+			prev := runes[idx-1]
+			nextIsLower := idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+
+			// Split before a word, including the last letter of an initialism followed by a word, e.g. HTTP|Request.
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				sb.WriteRune('_')
+			}
+		}
+
+		sb.WriteRune(unicode.ToLower(r))
+	}
+
+	return sb.String()
+}
+
+func pluralize(word string) string {
+	switch {
+	case word == "":
+		return word
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	default:
+		return word + "s"
+	}
+}
+
+// Returns the table for v, which may be a pointer to a struct or to a slice of structs.
+// The table comes from TableName when the struct implements Tabler, then from .Register,
+// and otherwise from the NamingStrategy applied to the name of the struct type.
+// Returns an error when the struct is registered for more than one table and doesn't implement Tabler.
+func (jdb *DB) TableFor(v any) (string, error) {
+	t := reflect.TypeOf(v)
+
+	if t == nil || t.Kind() != reflect.Ptr {
+		return "", errors.New("Value must be a pointer to a struct or a slice of structs")
+	}
+
+	t = t.Elem()
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return "", errors.New("Value must be a pointer to a struct or a slice of structs")
+	}
+
+	if tabler, ok := reflect.New(t).Interface().(Tabler); ok {
+		return tabler.TableName(), nil
+	}
+
+	tables := jdb.models.tablesFor(t)
+
+	if len(tables) == 1 {
+		return tables[0], nil
+	}
+
+	if len(tables) > 1 {
+		return "", fmt.Errorf("%s is registered for the %s tables, implement Tabler to choose one", t.Name(), strings.Join(tables, ", "))
+	}
+
+	if jdb.NamingStrategy != nil {
+		return jdb.NamingStrategy(t.Name()), nil
+	}
+
+	return SnakeCasePlural(t.Name()), nil
+}
+
+// Inserts v when its ID is the zero value and updates the row with its ID otherwise.
+// The table is inferred from v, see .TableFor.
+// v must be a pointer to a struct.
+func (jdb *DB) Save(v any) error {
+	table, err := jdb.TableFor(v)
+	if err != nil {
+		return err
+	}
+
+	if err := checkValueType(v); err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(v).Elem()
+	meta := getStructMeta(value.Type())
+
+	idx, ok := meta.byName[jdb.getIDName()]
+	if !ok {
+		return errors.New("Save requires a field tagged with the ID column")
+	}
+
+	id := value.FieldByIndex(meta.fields[idx].index)
+
+	if id.IsZero() {
+		return jdb.Insert(table, v)
+	}
+
+	return jdb.Update(table, id.Interface(), v)
+}
+
+// Gets a single row with the given id from the table inferred from v, see .TableFor.
+// v must be a pointer to a struct.
+func (jdb *DB) Find(v any, id any) error {
+	table, err := jdb.TableFor(v)
+	if err != nil {
+		return err
+	}
+
+	return jdb.Get(table, id, v)
+}
+
+// Starts a query on the table inferred from v, see .TableFor.
+// The model's tag options, such as softdelete, apply to the query even when it isn't given a struct, e.g. .Count.
+// v must be a pointer to a struct or a slice of structs.
+func (jdb *DB) Model(v any) QueryDB {
+	table, err := jdb.TableFor(v)
+
+	q := jdb.From(table)
+	q.err = err
+
+	if err == nil {
+		t := reflect.TypeOf(v).Elem()
+		if t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+
+		q.model = t
+	}
+
+	return q
+}
@@ -0,0 +1,146 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type HTTPRequest struct {
+	ID uint `db:"id"`
+}
+
+type Category struct {
+	ID   uint   `db:"id"`
+	Name string `db:"name"`
+}
+
+type Person struct {
+	ID   uint   `db:"id"`
+	Name string `db:"name"`
+}
+
+func (p *Person) TableName() string {
+	return "people"
+}
+
+func TestSnakeCasePlural(t *testing.T) {
+	cases := map[string]string{
+		"User":        "users",
+		"HTTPRequest": "http_requests",
+		"UserID":      "user_ids",
+		"Category":    "categories",
+		"Day":         "days",
+		"Address":     "addresses",
+		"Box":         "boxes",
+		"Branch":      "branches",
+		"OAuth2Token": "o_auth2_tokens",
+	}
+
+	for name, expected := range cases {
+		if table := SnakeCasePlural(name); table != expected {
+			t.Fatalf("Expected %s to become %s, got: %s\n", name, expected, table)
+		}
+	}
+}
+
+func TestInferredTables(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE people (id integer primary key, name text)")
+	db.Exec("CREATE TABLE categories (id integer primary key, name text)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	if table, _ := jdb.TableFor(&[]HTTPRequest{}); table != "http_requests" {
+		t.Fatalf("Expected the table to be inferred from the slice element, got: %s\n", table)
+	}
+
+	if _, err := jdb.TableFor(Person{}); err == nil {
+		t.Fatal("Expected an error inferring the table of a non-pointer")
+	}
+
+	registered := NewDB(nil)
+
+	if err := registered.Register("requests", &HTTPRequest{}); err != nil {
+		t.Fatalf("Failed to register HTTPRequest: %s\n", err.Error())
+	}
+
+	if table, _ := registered.TableFor(&HTTPRequest{}); table != "requests" {
+		t.Fatalf("Expected the registered table, got: %s\n", table)
+	}
+
+	if err := registered.Register("archived_requests", &HTTPRequest{}); err != nil {
+		t.Fatalf("Failed to register HTTPRequest for a second table: %s\n", err.Error())
+	}
+
+	if _, err := registered.TableFor(&HTTPRequest{}); err == nil {
+		t.Fatal("Expected an error inferring the table of a type registered for two tables")
+	}
+
+	// Registering another type for the table replaces it.
+	if err := registered.Register("archived_requests", &Category{}); err != nil {
+		t.Fatalf("Failed to register Category: %s\n", err.Error())
+	}
+
+	if table, _ := registered.TableFor(&HTTPRequest{}); table != "requests" {
+		t.Fatalf("Expected the replaced registration to be removed, got: %s\n", table)
+	}
+
+	if err := registered.Register("requests", &Category{}); err != nil {
+		t.Fatalf("Failed to register Category: %s\n", err.Error())
+	}
+
+	if table, _ := registered.TableFor(&HTTPRequest{}); table != "http_requests" {
+		t.Fatalf("Expected the replaced registration to be removed, got: %s\n", table)
+	}
+
+	person := Person{Name: "Joe"}
+
+	if err := jdb.Save(&person); err != nil {
+		t.Fatalf("Failed to save person: %s\n", err.Error())
+	}
+
+	if person.ID == 0 {
+		t.Fatal("Expected .Save to insert a person without an ID")
+	}
+
+	person.Name = "Jon"
+
+	if err := jdb.Save(&person); err != nil {
+		t.Fatalf("Failed to save person: %s\n", err.Error())
+	}
+
+	var found Person
+
+	if err := jdb.Find(&found, person.ID); err != nil || found.Name != "Jon" {
+		t.Fatalf("Expected .Save to update the person: %v %+v\n", err, found)
+	}
+
+	for _, name := range []string{"Books", "Music"} {
+		if err := jdb.Save(&Category{Name: name}); err != nil {
+			t.Fatalf("Failed to save category: %s\n", err.Error())
+		}
+	}
+
+	var categories []Category
+
+	if err := jdb.Model(&Category{}).Where("name = ?", "Music").All(&categories); err != nil || len(categories) != 1 {
+		t.Fatalf("Expected the table to be inferred by .Model: %v %+v\n", err, categories)
+	}
+
+	jdb.NamingStrategy = func(name string) string { return "missing_" + name }
+
+	if _, err := jdb.Model(&[]Category{}).Count(); err == nil {
+		t.Fatal("Expected the naming strategy to be used")
+	}
+
+	if _, err := jdb.Model(5).Count(); err == nil {
+		t.Fatal("Expected .Model to return an error when the query is executed")
+	}
+}
@@ -0,0 +1,181 @@
+package sqlj
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// The kind of operation sqlj is performing when it executes a query.
+type Operation string
+
+const (
+	GetOperation    Operation = "get"
+	SelectOperation Operation = "select"
+	InsertOperation Operation = "insert"
+	UpdateOperation Operation = "update"
+	DeleteOperation Operation = "delete"
+	CountOperation  Operation = "count"
+	ExistsOperation Operation = "exists"
+)
+
+// Describes a query executed by sqlj.
+// Table is empty for queries made with raw SQL such as .GetRow and .SelectAll.
+type QueryEvent struct {
+	Operation Operation
+	Table     string
+	SQL       string
+	Args      []any
+}
+
+// Allows you to observe every query executed by a DB, for example to log or time them.
+// Before is called before the query is sent and After once the results have been read.
+type QueryHook interface {
+	Before(ctx context.Context, event QueryEvent)
+	After(ctx context.Context, event QueryEvent, err error, duration time.Duration)
+}
+
+// The context aware methods of DB and Tx in the database/sql standard library.
+// These are used in place of the DBLike methods when available so the context reaches the database.
+// Prepared statements are run through the same interface, see .runner.
+type contextDBLike interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Returns a copy of the DB that passes ctx to the hooks and to the underlying DB where it supports it.
+func (jdb *DB) WithContext(ctx context.Context) *DB {
+	db := *jdb
+	db.ctx = ctx
+
+	return &db
+}
+
+func (jdb *DB) context() context.Context {
+	if jdb.ctx == nil {
+		return context.Background()
+	}
+
+	return jdb.ctx
+}
+
+// Executes a query expected to return a single row and passes it to scan.
+func (jdb *DB) queryRow(event QueryEvent, scan func(row *sql.Row) error) error {
+	ctx, finish := jdb.instrument(event)
+
+	db, release, err := jdb.runner(ctx, event.SQL)
+	if err != nil {
+		finish(err, 0)
+
+		return err
+	}
+
+	defer release()
+
+	err = scan(db.QueryRowContext(ctx, event.SQL, event.Args...))
+
+	var rows int64
+	if err == nil {
+		rows = 1
+	}
+
+	finish(err, rows)
+
+	return err
+}
+
+// Executes a query and passes the rows to scan. The rows are closed afterwards.
+// scan should return the number of rows it read.
+func (jdb *DB) query(event QueryEvent, scan func(rows *sql.Rows) (int64, error)) error {
+	ctx, finish := jdb.instrument(event)
+
+	db, release, err := jdb.runner(ctx, event.SQL)
+	if err != nil {
+		finish(err, 0)
+
+		return err
+	}
+
+	defer release()
+
+	rows, err := db.QueryContext(ctx, event.SQL, event.Args...)
+
+	var n int64
+	if err == nil {
+		n, err = scan(rows)
+		rows.Close()
+	}
+
+	finish(err, n)
+
+	return err
+}
+
+// Executes a query that doesn't return rows.
+func (jdb *DB) exec(event QueryEvent) (sql.Result, error) {
+	ctx, finish := jdb.instrument(event)
+
+	db, release, err := jdb.runner(ctx, event.SQL)
+	if err != nil {
+		finish(err, 0)
+
+		return nil, err
+	}
+
+	defer release()
+
+	result, err := db.ExecContext(ctx, event.SQL, event.Args...)
+
+	var rows int64
+	if err == nil {
+		// Not every driver reports the rows affected, in which case zero is recorded.
+		rows, _ = result.RowsAffected()
+	}
+
+	finish(err, rows)
+
+	return result, err
+}
+
+// Starts the span, calls the hooks and records the metrics for a query.
+// The returned context should be used to execute the query and finish must be called once it is complete.
+func (jdb *DB) instrument(event QueryEvent) (context.Context, func(err error, rows int64)) {
+	ctx := jdb.context()
+
+	var span Span
+	if jdb.Tracer != nil {
+		ctx, span = jdb.Tracer.Start(ctx, "sqlj."+string(event.Operation))
+		span.SetAttribute(OperationAttribute, string(event.Operation))
+		span.SetAttribute(TableAttribute, event.Table)
+		span.SetAttribute(StatementAttribute, event.SQL)
+	}
+
+	if jdb.Hook != nil {
+		jdb.Hook.Before(ctx, event)
+	}
+
+	start := time.Now()
+
+	return ctx, func(err error, rows int64) {
+		duration := time.Since(start)
+
+		if jdb.Hook != nil {
+			jdb.Hook.After(ctx, event, err, duration)
+		}
+
+		if jdb.Metrics != nil {
+			jdb.Metrics.RecordQuery(event.Operation, event.Table, duration, err)
+		}
+
+		if span != nil {
+			span.SetAttribute(RowsAttribute, rows)
+
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			span.End()
+		}
+	}
+}
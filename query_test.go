@@ -0,0 +1,113 @@
+package sqlj
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingHook struct {
+	before []QueryEvent
+	after  []QueryEvent
+	errs   []error
+}
+
+func (h *recordingHook) Before(ctx context.Context, event QueryEvent) {
+	h.before = append(h.before, event)
+}
+
+func (h *recordingHook) After(ctx context.Context, event QueryEvent, err error, duration time.Duration) {
+	h.after = append(h.after, event)
+	h.errs = append(h.errs, err)
+}
+
+func TestQueryHook(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	hook := &recordingHook{}
+
+	jdb := NewDB(db)
+	jdb.Hook = hook
+
+	user := User{Name: "Joe", Email: "joe@example.com"}
+
+	if err := jdb.InsertWithFields("user", &user, map[string]string{"created_at": "date()"}); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	if _, err := jdb.From("user").Where("name = ?", "Joe").Count(); err != nil {
+		t.Fatalf("Failed to count users: %s\n", err.Error())
+	}
+
+	var missing User
+	if err := jdb.Get("user", 100, &missing); err == nil {
+		t.Fatal("Expected an error retrieving a missing user")
+	}
+
+	if err := jdb.Delete("user", user.ID); err != nil {
+		t.Fatalf("Failed to delete user: %s\n", err.Error())
+	}
+
+	if len(hook.before) != 4 || len(hook.after) != 4 {
+		t.Fatalf("Expected 4 hook calls, got: %d before and %d after\n", len(hook.before), len(hook.after))
+	}
+
+	operations := []Operation{InsertOperation, CountOperation, GetOperation, DeleteOperation}
+
+	for idx, event := range hook.after {
+		if event.Operation != operations[idx] || event.Table != "user" {
+			t.Fatalf("Unexpected event: %+v\n", event)
+		}
+	}
+
+	if hook.errs[0] != nil || hook.errs[2] != sql.ErrNoRows {
+		t.Fatalf("Unexpected hook errors: %v\n", hook.errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := jdb.WithContext(ctx).From("user").Count(); err == nil {
+		t.Fatal("Expected an error using a cancelled context")
+	}
+}
+
+func TestSlogHook(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	hook := NewSlogHook(logger, 100*time.Millisecond)
+	event := QueryEvent{Operation: SelectOperation, Table: "user", SQL: "SELECT id FROM user"}
+
+	hook.After(context.Background(), event, nil, time.Millisecond)
+
+	if !strings.Contains(buf.String(), "level=DEBUG") || !strings.Contains(buf.String(), "table=user") {
+		t.Fatalf("Unexpected debug log: %s\n", buf.String())
+	}
+
+	buf.Reset()
+	hook.After(context.Background(), event, nil, time.Second)
+
+	if !strings.Contains(buf.String(), "level=WARN") {
+		t.Fatalf("Expected a slow query warning, got: %s\n", buf.String())
+	}
+
+	buf.Reset()
+	hook.After(context.Background(), event, sql.ErrNoRows, time.Second)
+
+	if !strings.Contains(buf.String(), "level=ERROR") {
+		t.Fatalf("Expected an error log, got: %s\n", buf.String())
+	}
+}
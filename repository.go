@@ -0,0 +1,489 @@
+package sqlj
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// The common operations on the rows of a table as values of type T.
+// SQLRepository implements it with a DB and MemoryRepository in memory for unit tests.
+type Repository[T any] interface {
+	Get(id any) (T, error)
+	List(query ListQuery) ([]T, error)
+	Create(v *T) error
+	Update(id any, v *T) error
+	Delete(id any) error
+	Count(query ListQuery) (uint, error)
+	Exists(id any) (bool, error)
+	Paginate(query ListQuery, page uint, pageSize uint) ([]T, PageInfo, error)
+}
+
+// Describes the rows to return from a Repository.
+// Where matches each column to a value by equality so it can be evaluated in memory too.
+// Use the DB directly for anything more complex.
+type ListQuery struct {
+	Where      map[string]any
+	OrderBy    string // A column
+	Descending bool
+	Limit      uint // Ignored by .Paginate
+	Offset     uint // Ignored by .Paginate
+}
+
+var (
+	_ Repository[struct{}] = (*SQLRepository[struct{}])(nil)
+	_ Repository[struct{}] = (*MemoryRepository[struct{}])(nil)
+)
+
+// A Repository for a table in a DB.
+type SQLRepository[T any] struct {
+	DB    *DB
+	Table string
+}
+
+// Returns a Repository for the table. T must be a struct.
+func NewRepository[T any](db *DB, table string) *SQLRepository[T] {
+	return &SQLRepository[T]{DB: db, Table: table}
+}
+
+func (r *SQLRepository[T]) Get(id any) (T, error) {
+	var v T
+	err := r.DB.Get(r.Table, id, &v)
+
+	return v, err
+}
+
+func (r *SQLRepository[T]) List(query ListQuery) ([]T, error) {
+	q := r.query(query)
+
+	if query.Limit > 0 {
+		q = q.Limit(query.Limit)
+	}
+
+	if query.Offset > 0 {
+		q = q.Offset(query.Offset)
+	}
+
+	rows := []T{}
+	err := q.All(&rows)
+
+	return rows, err
+}
+
+func (r *SQLRepository[T]) Create(v *T) error {
+	return r.DB.Insert(r.Table, v)
+}
+
+func (r *SQLRepository[T]) Update(id any, v *T) error {
+	return r.DB.Update(r.Table, id, v)
+}
+
+func (r *SQLRepository[T]) Delete(id any) error {
+	return r.DB.DeleteValue(r.Table, id, new(T))
+}
+
+func (r *SQLRepository[T]) Count(query ListQuery) (uint, error) {
+	return r.query(query).count(reflect.TypeFor[T]())
+}
+
+func (r *SQLRepository[T]) Exists(id any) (bool, error) {
+	return r.query(ListQuery{Where: map[string]any{r.DB.getIDName(): id}}).exists(reflect.TypeFor[T]())
+}
+
+func (r *SQLRepository[T]) Paginate(query ListQuery, page uint, pageSize uint) ([]T, PageInfo, error) {
+	rows := []T{}
+	info, err := r.query(query).Paginate(page, pageSize, &rows)
+
+	return rows, info, err
+}
+
+// Builds the where and order clauses for the query.
+func (r *SQLRepository[T]) query(query ListQuery) QueryDB {
+	q := r.DB.From(r.Table)
+
+	// Sorted so the generated SQL is stable.
+	for _, column := range slices.Sorted(maps.Keys(query.Where)) {
+		q = q.Where(columnEq(column), query.Where[column])
+	}
+
+	if query.OrderBy != "" {
+		direction := "ASC"
+		if query.Descending {
+			direction = "DESC"
+		}
+
+		q = q.Order(query.OrderBy, direction)
+	}
+
+	return q
+}
+
+// An in-memory Repository for unit tests.
+// Rows are matched to the db tags of T and Create assigns incrementing IDs to integer ID fields that are zero.
+// Tag options and lifecycle hooks are not applied.
+type MemoryRepository[T any] struct {
+	IDColumn string
+
+	mu     sync.Mutex
+	rows   []T
+	nextID int64
+}
+
+// Returns an empty in-memory Repository. T must be a struct.
+func NewMemoryRepository[T any]() *MemoryRepository[T] {
+	return &MemoryRepository[T]{IDColumn: "id"}
+}
+
+func (r *MemoryRepository[T]) Get(id any) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if idx := r.find(id); idx >= 0 {
+		return r.rows[idx], nil
+	}
+
+	var zero T
+
+	return zero, sql.ErrNoRows
+}
+
+func (r *MemoryRepository[T]) List(query ListQuery) ([]T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.filter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	start := min(int(query.Offset), len(rows))
+	rows = rows[start:]
+
+	if query.Limit > 0 && int(query.Limit) < len(rows) {
+		rows = rows[:query.Limit]
+	}
+
+	return rows, nil
+}
+
+func (r *MemoryRepository[T]) Create(v *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := r.idField(reflect.ValueOf(v).Elem())
+	if err != nil {
+		return err
+	}
+
+	if id.IsZero() {
+		if !isInteger(id.Kind()) {
+			return errors.New("Create requires an ID or an integer ID field")
+		}
+
+		// Skip any IDs taken by rows created with an explicit ID.
+		for id.IsZero() || r.find(id.Interface()) >= 0 {
+			r.nextID++
+
+			if isUnsigned(id.Kind()) {
+				id.SetUint(uint64(r.nextID))
+			} else {
+				id.SetInt(r.nextID)
+			}
+		}
+	} else if r.find(id.Interface()) >= 0 {
+		return fmt.Errorf("A row with the ID %v already exists", id.Interface())
+	} else if isUnsigned(id.Kind()) && id.Uint() <= math.MaxInt64 {
+		r.nextID = max(r.nextID, int64(id.Uint()))
+	} else if isInteger(id.Kind()) && !isUnsigned(id.Kind()) {
+		r.nextID = max(r.nextID, id.Int())
+	}
+
+	r.rows = append(r.rows, *v)
+
+	return nil
+}
+
+func (r *MemoryRepository[T]) Update(id any, v *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.find(id)
+	if idx < 0 {
+		return sql.ErrNoRows
+	}
+
+	r.rows[idx] = *v
+
+	return nil
+}
+
+func (r *MemoryRepository[T]) Delete(id any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if idx := r.find(id); idx >= 0 {
+		r.rows = slices.Delete(r.rows, idx, idx+1)
+	}
+
+	return nil
+}
+
+func (r *MemoryRepository[T]) Count(query ListQuery) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.filter(query)
+
+	return uint(len(rows)), err
+}
+
+func (r *MemoryRepository[T]) Exists(id any) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.find(id) >= 0, nil
+}
+
+func (r *MemoryRepository[T]) Paginate(query ListQuery, page uint, pageSize uint) ([]T, PageInfo, error) {
+	if page < 1 {
+		return nil, PageInfo{}, errors.New("Page number must be greater than 0")
+	}
+
+	if pageSize < 1 {
+		return nil, PageInfo{}, errors.New("Page size must be greater than 0")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows, err := r.filter(query)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	info := newPageInfo(page, pageSize, uint(len(rows)))
+
+	start := min(int((page-1)*pageSize), len(rows))
+	end := min(start+int(pageSize), len(rows))
+
+	return rows[start:end], info, nil
+}
+
+// Returns the index of the row with the ID, or -1.
+func (r *MemoryRepository[T]) find(id any) int {
+	for idx := range r.rows {
+		rowID, err := r.idField(reflect.ValueOf(&r.rows[idx]).Elem())
+
+		if err == nil && valuesEqual(rowID, id) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+func (r *MemoryRepository[T]) idField(v reflect.Value) (reflect.Value, error) {
+	return columnField(v, r.IDColumn)
+}
+
+// Returns a copy of the rows matching the where conditions in the query order.
+func (r *MemoryRepository[T]) filter(query ListQuery) ([]T, error) {
+	// The columns are checked up front so an unknown column is an error even when there are no rows.
+	var zero T
+
+	for column := range query.Where {
+		if _, err := columnField(reflect.ValueOf(&zero).Elem(), column); err != nil {
+			return nil, err
+		}
+	}
+
+	if query.OrderBy != "" {
+		if _, err := columnField(reflect.ValueOf(&zero).Elem(), query.OrderBy); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := []T{}
+
+	for _, row := range r.rows {
+		match := true
+
+		for column, value := range query.Where {
+			field, _ := columnField(reflect.ValueOf(&row).Elem(), column)
+
+			if !valuesEqual(field, value) {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			rows = append(rows, row)
+		}
+	}
+
+	if query.OrderBy != "" {
+		slices.SortStableFunc(rows, func(a T, b T) int {
+			fieldA, _ := columnField(reflect.ValueOf(&a).Elem(), query.OrderBy)
+			fieldB, _ := columnField(reflect.ValueOf(&b).Elem(), query.OrderBy)
+
+			if query.Descending {
+				return compareValues(fieldB, fieldA)
+			}
+
+			return compareValues(fieldA, fieldB)
+		})
+	}
+
+	return rows, nil
+}
+
+// Returns the field of the struct value v tagged with the column.
+func columnField(v reflect.Value, column string) (reflect.Value, error) {
+	meta := getStructMeta(v.Type())
+
+	idx, ok := meta.byName[column]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("Column %q does not match a db field", column)
+	}
+
+	return v.FieldByIndex(meta.fields[idx].index), nil
+}
+
+// Reports whether the field holds the value.
+// The value is only converted to the field's type between integers, between floats or to a type of the same kind,
+// such as a named string type, and only when it is preserved. So the same rows match as they would in SQL,
+// e.g. 65 doesn't match "A" and 1.9 doesn't match 1. A nil value matches nothing, like col = NULL.
+func valuesEqual(field reflect.Value, value any) bool {
+	v := reflect.ValueOf(value)
+
+	if !v.IsValid() {
+		return false
+	}
+
+	if field.Kind() == reflect.Ptr && v.Kind() != reflect.Ptr {
+		if field.IsNil() {
+			return false
+		}
+
+		field = field.Elem()
+	}
+
+	if v.Type() != field.Type() {
+		converted, ok := convertValue(v, field.Type())
+		if !ok {
+			return false
+		}
+
+		v = converted
+	}
+
+	return reflect.DeepEqual(field.Interface(), v.Interface())
+}
+
+// Converts an integer to another integer type, a float to another float type or a value to a type of the same kind,
+// when the value is preserved.
+func convertValue(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	switch {
+	case isInteger(v.Kind()) && isInteger(t.Kind()):
+		if !isUnsigned(v.Kind()) && v.Int() < 0 && isUnsigned(t.Kind()) {
+			return reflect.Value{}, false
+		}
+
+		converted := v.Convert(t)
+
+		if isUnsigned(v.Kind()) && !isUnsigned(t.Kind()) && converted.Int() < 0 {
+			return reflect.Value{}, false
+		}
+
+		if !converted.Convert(v.Type()).Equal(v) {
+			return reflect.Value{}, false
+		}
+
+		return converted, true
+	case isFloat(v.Kind()) && isFloat(t.Kind()):
+		converted := v.Convert(t)
+
+		if !converted.Convert(v.Type()).Equal(v) {
+			return reflect.Value{}, false
+		}
+
+		return converted, true
+	case v.Kind() == t.Kind() && v.CanConvert(t):
+		return v.Convert(t), true
+	}
+
+	return reflect.Value{}, false
+}
+
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+
+	return isUnsigned(kind)
+}
+
+func isUnsigned(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// Compares two values of the same type for sorting. Nil pointers sort first.
+func compareValues(a reflect.Value, b reflect.Value) int {
+	if a.Kind() == reflect.Ptr {
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0
+		case a.IsNil():
+			return -1
+		case b.IsNil():
+			return 1
+		}
+
+		a, b = a.Elem(), b.Elem()
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp.Compare(boolInt(a.Bool()), boolInt(b.Bool()))
+	}
+
+	if ta, ok := a.Interface().(time.Time); ok {
+		return ta.Compare(b.Interface().(time.Time))
+	}
+
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
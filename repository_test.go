@@ -0,0 +1,159 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type Task struct {
+	ID       uint    `db:"id"`
+	Title    string  `db:"title"`
+	Priority int     `db:"priority"`
+	Done     bool    `db:"done"`
+	Notes    *string `db:"notes"`
+}
+
+// Runs the same checks against every Repository implementation so the fake behaves like the DB.
+func testRepository(t *testing.T, repo Repository[Task]) {
+	for idx, title := range []string{"Write", "Review", "Ship", "Celebrate"} {
+		task := Task{Title: title, Priority: idx % 2}
+
+		if err := repo.Create(&task); err != nil {
+			t.Fatalf("Failed to create task: %s\n", err.Error())
+		}
+
+		if task.ID != uint(idx+1) {
+			t.Fatalf("Expected the task to be given an ID, got: %d\n", task.ID)
+		}
+	}
+
+	task, err := repo.Get(3)
+
+	if err != nil || task.Title != "Ship" {
+		t.Fatalf("Failed to get task: %v %+v\n", err, task)
+	}
+
+	if _, err := repo.Get(42); err != sql.ErrNoRows {
+		t.Fatalf("Expected sql.ErrNoRows for a missing task, got: %v\n", err)
+	}
+
+	task.Done = true
+
+	if err := repo.Update(task.ID, &task); err != nil {
+		t.Fatalf("Failed to update task: %s\n", err.Error())
+	}
+
+	if err := repo.Update(42, &task); err != sql.ErrNoRows {
+		t.Fatalf("Expected sql.ErrNoRows updating a missing task, got: %v\n", err)
+	}
+
+	tasks, err := repo.List(ListQuery{Where: map[string]any{"priority": 1}, OrderBy: "title", Descending: true})
+
+	if err != nil || len(tasks) != 2 || tasks[0].Title != "Review" || tasks[1].Title != "Celebrate" {
+		t.Fatalf("Unexpected tasks: %v %+v\n", err, tasks)
+	}
+
+	// Values are only compared as they would be in SQL, e.g. 65 isn't "A" and 1.9 isn't 1.
+	for _, where := range []map[string]any{{"title": 65}, {"priority": 1.9}} {
+		if count, err := repo.Count(ListQuery{Where: where}); err != nil || count != 0 {
+			t.Fatalf("Expected no tasks to match %v, got: %d %v\n", where, count, err)
+		}
+	}
+
+	// A nil value compiles to notes = NULL, which is never true.
+	if count, err := repo.Count(ListQuery{Where: map[string]any{"notes": nil}}); err != nil || count != 0 {
+		t.Fatalf("Expected no tasks to match a nil value, got: %d %v\n", count, err)
+	}
+
+	if count, err := repo.Count(ListQuery{Where: map[string]any{"priority": int64(1)}}); err != nil || count != 2 {
+		t.Fatalf("Expected 2 tasks with priority 1, got: %d %v\n", count, err)
+	}
+
+	tasks, err = repo.List(ListQuery{OrderBy: "id", Limit: 2, Offset: 1})
+
+	if err != nil || len(tasks) != 2 || tasks[0].ID != 2 || tasks[1].ID != 3 {
+		t.Fatalf("Unexpected page of tasks: %v %+v\n", err, tasks)
+	}
+
+	if count, err := repo.Count(ListQuery{Where: map[string]any{"done": true}}); err != nil || count != 1 {
+		t.Fatalf("Expected 1 done task, got: %d %v\n", count, err)
+	}
+
+	tasks, info, err := repo.Paginate(ListQuery{OrderBy: "id"}, 2, 3)
+
+	if err != nil || len(tasks) != 1 || tasks[0].ID != 4 || info.Total != 4 || info.TotalPages != 2 || info.HasNext {
+		t.Fatalf("Unexpected pagination: %v %+v %+v\n", err, tasks, info)
+	}
+
+	if err := repo.Delete(1); err != nil {
+		t.Fatalf("Failed to delete task: %s\n", err.Error())
+	}
+
+	if exists, err := repo.Exists(1); err != nil || exists {
+		t.Fatalf("Expected the deleted task not to exist: %v\n", err)
+	}
+
+	if exists, err := repo.Exists(2); err != nil || !exists {
+		t.Fatalf("Expected the task to exist: %v\n", err)
+	}
+
+	if _, err := repo.List(ListQuery{Where: map[string]any{"missing": 1}}); err == nil {
+		t.Fatal("Expected an error for an unknown column")
+	}
+}
+
+func TestRepository(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE task (id integer primary key, title text, priority integer, done boolean not null default false, notes text)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	testRepository(t, NewRepository[Task](&jdb, "task"))
+}
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, NewMemoryRepository[Task]())
+
+	repo := NewMemoryRepository[Task]()
+
+	for _, id := range []uint{2, 0, 0} {
+		task := Task{ID: id}
+
+		if err := repo.Create(&task); err != nil {
+			t.Fatalf("Failed to create task: %s\n", err.Error())
+		}
+	}
+
+	// The generated IDs follow the explicit one rather than reusing it.
+	tasks, _ := repo.List(ListQuery{OrderBy: "id"})
+
+	if len(tasks) != 3 || tasks[0].ID != 2 || tasks[1].ID != 3 || tasks[2].ID != 4 {
+		t.Fatalf("Unexpected task IDs: %+v\n", tasks)
+	}
+
+	repo = NewMemoryRepository[Task]()
+
+	// An explicit ID after generated ones is never given out again.
+	for _, id := range []uint{0, 2, 0} {
+		task := Task{ID: id}
+
+		if err := repo.Create(&task); err != nil {
+			t.Fatalf("Failed to create task: %s\n", err.Error())
+		}
+	}
+
+	if count, _ := repo.Count(ListQuery{Where: map[string]any{"id": 2}}); count != 1 {
+		t.Fatalf("Expected one task with the ID 2, got: %d\n", count)
+	}
+
+	if err := repo.Create(&Task{ID: 3}); err == nil {
+		t.Fatal("Expected an error creating a task with an existing ID")
+	}
+}
@@ -0,0 +1,108 @@
+package sqlj
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+)
+
+// Gets a single value using the supplied SQL and values, e.g. SELECT max(age) FROM users.
+// dest must be a pointer to a type the driver can scan into, such as *int or *sql.NullString.
+func (jdb *DB) Scalar(sql string, dest any, values ...any) error {
+	return jdb.scalar(QueryEvent{Operation: GetOperation, SQL: sql, Args: values}, dest)
+}
+
+// Executes a query for a single row with a single column and scans it into dest.
+func (jdb *DB) scalar(event QueryEvent, dest any) error {
+	return jdb.queryRow(event, func(row *sql.Row) error {
+		return row.Scan(dest)
+	})
+}
+
+// Selects all rows using the supplied SQL and values into maps of column to value.
+// The values are those returned by the driver, e.g. int64, float64, string, []byte, time.Time or nil.
+func (jdb *DB) SelectMaps(query string, values ...any) ([]map[string]any, error) {
+	results := []map[string]any{}
+
+	err := jdb.query(QueryEvent{Operation: SelectOperation, SQL: query, Args: values}, func(rows *sql.Rows) (int64, error) {
+		columns, err := rows.Columns()
+		if err != nil {
+			return 0, err
+		}
+
+		row := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+
+		for idx := range row {
+			pointers[idx] = &row[idx]
+		}
+
+		for rows.Next() {
+			if err := rows.Scan(pointers...); err != nil {
+				return int64(len(results)), err
+			}
+
+			result := make(map[string]any, len(columns))
+			for idx, column := range columns {
+				result[column] = row[idx]
+			}
+
+			results = append(results, result)
+		}
+
+		return int64(len(results)), rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Selects a single column from the query into dest.
+// dest must be a pointer to a slice of a type the driver can scan into, e.g. *[]string.
+func (q QueryDB) Pluck(column string, dest any) error {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
+		return errors.New("dest must be a pointer to a slice")
+	}
+
+	query, values, err := q.scoped(nil).selectQuery([]string{column})
+	if err != nil {
+		return err
+	}
+
+	start := val.Elem().Len()
+
+	err = q.DB.query(QueryEvent{SelectOperation, q.From, query, values}, func(rows *sql.Rows) (int64, error) {
+		return scanRowsIntoValues(rows, val.Elem())
+	})
+
+	if err != nil {
+		return err
+	}
+
+	q.restoreOrder(val.Elem(), start)
+
+	return nil
+}
+
+// Appends the first column of each row to the slice.
+// Returns the number of rows scanned.
+func scanRowsIntoValues(rows *sql.Rows, slice reflect.Value) (int64, error) {
+	item := reflect.New(slice.Type().Elem())
+
+	var count int64
+
+	for rows.Next() {
+		if err := rows.Scan(item.Interface()); err != nil {
+			return count, err
+		}
+
+		slice.Set(reflect.Append(slice, item.Elem()))
+		count++
+	}
+
+	return count, rows.Err()
+}
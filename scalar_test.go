@@ -0,0 +1,87 @@
+package sqlj
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestScalarPluckAndSelectMaps(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	for _, name := range []string{"Joe", "Jen", "Jon"} {
+		if err := jdb.Insert("user", &User{Name: name, Email: name + "@example.com"}); err != nil {
+			t.Fatalf("Failed to insert user: %s\n", err.Error())
+		}
+	}
+
+	var maxID int
+
+	if err := jdb.Scalar("SELECT max(id) FROM user WHERE name <> $1", &maxID, "Jon"); err != nil || maxID != 2 {
+		t.Fatalf("Expected a max ID of 2, got: %d %v\n", maxID, err)
+	}
+
+	var missing sql.NullString
+
+	if err := jdb.Scalar("SELECT email FROM user WHERE name = $1", &missing, "Nobody"); err != sql.ErrNoRows {
+		t.Fatalf("Expected sql.ErrNoRows, got: %v\n", err)
+	}
+
+	emails := []string{}
+
+	if err := jdb.From("user").Where("name <> ?", "Jen").Order("id", "DESC").Pluck("email", &emails); err != nil {
+		t.Fatalf("Failed to pluck emails: %s\n", err.Error())
+	}
+
+	if !reflect.DeepEqual(emails, []string{"Jon@example.com", "Joe@example.com"}) {
+		t.Fatalf("Unexpected emails: %v\n", emails)
+	}
+
+	if err := jdb.From("user").Pluck("email", emails); err == nil {
+		t.Fatal("Expected an error plucking into a non-pointer")
+	}
+
+	query := jdb.From("user").Order("id", "ASC")
+	users := []User{}
+
+	if err := query.All(&users); err != nil {
+		t.Fatalf("Failed to select users: %s\n", err.Error())
+	}
+
+	last := users[2:]
+	cursors, _ := query.Cursors(&last)
+	emails = []string{}
+
+	// Plucked values come back in the same order as .All for a .Before cursor.
+	if err := query.Before(cursors.Prev).Limit(2).Pluck("email", &emails); err != nil {
+		t.Fatalf("Failed to pluck emails: %s\n", err.Error())
+	}
+
+	if !reflect.DeepEqual(emails, []string{"Joe@example.com", "Jen@example.com"}) {
+		t.Fatalf("Unexpected emails before the cursor: %v\n", emails)
+	}
+
+	rows, err := jdb.SelectMaps("SELECT id, name, created_at FROM user WHERE id <= $1 ORDER BY id", 2)
+
+	if err != nil {
+		t.Fatalf("Failed to select maps: %s\n", err.Error())
+	}
+
+	if len(rows) != 2 || rows[0]["id"] != int64(1) || rows[1]["name"] != "Jen" || rows[0]["created_at"] == nil {
+		t.Fatalf("Unexpected rows: %v\n", rows)
+	}
+
+	if count, err := jdb.From("user").Count(); err != nil || count != 3 {
+		t.Fatalf("Expected a count of 3, got: %d %v\n", count, err)
+	}
+}
@@ -0,0 +1,112 @@
+package sqlj
+
+import (
+	"slices"
+)
+
+// The name of the scope added by .WithTenant.
+const TenantScope = "tenant"
+
+// A named set of where clauses applied to every query made by a DB.
+type scope struct {
+	name  string
+	apply func(QueryDB) QueryDB
+
+	// Set for a tenant scope so inserts write the tenant to the column.
+	column string
+	value  any
+}
+
+// Returns a copy of the DB that applies fn to every query made with it, including .Get, .Select, .Update and .Delete.
+// Only the where clauses added by fn are used. These are combined with AND and nested,
+// so the scope applies to every row regardless of any OR conditions on a query.
+// A scope can be removed with .Unscoped on the DB or on a single query.
+func (jdb *DB) WithScope(name string, fn func(QueryDB) QueryDB) *DB {
+	return jdb.withScope(scope{name: name, apply: fn})
+}
+
+// Returns a copy of the DB scoped to rows where column equals tenant.
+// Inserts made with the DB write tenant to the column.
+// The scope is named TenantScope.
+func (jdb *DB) WithTenant(column string, tenant any) *DB {
+	return jdb.withScope(scope{
+		name: TenantScope,
+		apply: func(q QueryDB) QueryDB {
+			return q.Where(columnEq(column), tenant)
+		},
+		column: column,
+		value:  tenant,
+	})
+}
+
+func (jdb *DB) withScope(s scope) *DB {
+	db := *jdb
+
+	// A scope with the same name is replaced.
+	db.scopes = slices.DeleteFunc(slices.Clone(jdb.scopes), func(existing scope) bool {
+		return existing.name == s.name
+	})
+	db.scopes = append(db.scopes, s)
+
+	return &db
+}
+
+// Returns a copy of the DB without the named scopes, or without any scopes when no names are given.
+func (jdb *DB) Unscoped(names ...string) *DB {
+	db := *jdb
+	db.scopes = removeScopes(jdb.scopes, names)
+
+	return &db
+}
+
+// Removes the named scopes from the query, or every scope when no names are given.
+// This doesn't affect soft deletes, see .WithDeleted.
+func (q QueryDB) Unscoped(names ...string) QueryDB {
+	db := q.DB.Unscoped(names...)
+	q.DB = db
+
+	return q
+}
+
+func removeScopes(scopes []scope, names []string) []scope {
+	if len(names) == 0 {
+		return nil
+	}
+
+	return slices.DeleteFunc(slices.Clone(scopes), func(s scope) bool {
+		return slices.Contains(names, s.name)
+	})
+}
+
+// Returns the where clauses and values of the scopes for the table.
+// Each scope is nested so its clauses are combined with AND.
+func (jdb *DB) scopeWhere(table string) ([]WhereClause, []any) {
+	where := []WhereClause{}
+	values := []any{}
+
+	for _, s := range jdb.scopes {
+		sq := s.apply(QueryDB{DB: jdb, From: table})
+
+		if len(sq.WhereClauses) == 0 {
+			continue
+		}
+
+		where = append(where, WhereClause{AND_TYPE, NestedExpr{sq.WhereClauses}})
+		values = append(values, sq.WhereValues...)
+	}
+
+	return where, values
+}
+
+// Returns the fields inserts should write for the tenant scopes.
+func (jdb *DB) scopeFields() []field {
+	fields := []field{}
+
+	for _, s := range jdb.scopes {
+		if s.column != "" {
+			fields = append(fields, basicField{Name: s.column, Value: s.value})
+		}
+	}
+
+	return fields
+}
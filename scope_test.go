@@ -0,0 +1,106 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type Project struct {
+	ID       uint   `db:"id"`
+	TenantID int    `db:"tenant_id"`
+	Name     string `db:"name"`
+	Archived bool   `db:"archived"`
+}
+
+func TestScopes(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE project (id integer primary key, tenant_id integer, name text, archived boolean not null default false)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	base := NewDB(db)
+
+	acme := base.WithTenant("tenant_id", 1)
+	globex := base.WithTenant("tenant_id", 2)
+
+	for _, name := range []string{"Rocket", "Anvil"} {
+		if err := acme.Insert("project", &Project{Name: name}); err != nil {
+			t.Fatalf("Failed to insert project: %s\n", err.Error())
+		}
+	}
+
+	other := Project{Name: "Omega", TenantID: 1}
+
+	if err := globex.Insert("project", &other); err != nil {
+		t.Fatalf("Failed to insert project: %s\n", err.Error())
+	}
+
+	if other.TenantID != 2 {
+		t.Fatalf("Expected the insert to be stamped with the tenant, got: %d\n", other.TenantID)
+	}
+
+	var projects []Project
+
+	if err := acme.Select("project", &projects); err != nil || len(projects) != 2 {
+		t.Fatalf("Expected the tenant's projects: %v %+v\n", err, projects)
+	}
+
+	var project Project
+
+	if err := acme.Get("project", other.ID, &project); err != sql.ErrNoRows {
+		t.Fatalf("Expected another tenant's project to be hidden from .Get, got: %v\n", err)
+	}
+
+	query, _, _ := acme.From("project").Where("name = ?", "Rocket").OrWhere("name = ?", "Omega").ToSQL()
+
+	if query != "SELECT * FROM project WHERE (name = $1 OR name = $2) AND (tenant_id = $3)" {
+		t.Fatalf("Unexpected scoped SQL: %s\n", query)
+	}
+
+	projects = nil
+
+	if err := acme.From("project").Where("name = ?", "Rocket").OrWhere("name = ?", "Omega").All(&projects); err != nil || len(projects) != 1 {
+		t.Fatalf("Expected the OR condition to stay within the tenant: %v %+v\n", err, projects)
+	}
+
+	other.Name = "Hijacked"
+
+	if err := acme.Update("project", other.ID, &other); err != sql.ErrNoRows {
+		t.Fatalf("Expected another tenant's project to be hidden from .Update, got: %v\n", err)
+	}
+
+	acme.Delete("project", other.ID)
+
+	if count, _ := base.From("project").Count(); count != 3 {
+		t.Fatalf("Expected another tenant's project to be hidden from .Delete, got a count of: %d\n", count)
+	}
+
+	active := acme.WithScope("active", func(q QueryDB) QueryDB {
+		return q.Where("archived = ?", false)
+	})
+
+	if err := active.Patch("project", projects[0].ID, &Project{Archived: true}); err != nil {
+		t.Fatalf("Failed to archive project: %s\n", err.Error())
+	}
+
+	if count, _ := active.From("project").Count(); count != 1 {
+		t.Fatalf("Expected 1 active project, got: %d\n", count)
+	}
+
+	if count, _ := active.From("project").Unscoped("active").Count(); count != 2 {
+		t.Fatalf("Expected 2 projects without the active scope, got: %d\n", count)
+	}
+
+	if count, _ := active.From("project").Unscoped().Count(); count != 3 {
+		t.Fatalf("Expected 3 projects without any scope, got: %d\n", count)
+	}
+
+	if count, _ := active.Unscoped(TenantScope).From("project").Count(); count != 2 {
+		t.Fatalf("Expected 2 active projects across tenants, got: %d\n", count)
+	}
+}
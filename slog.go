@@ -0,0 +1,46 @@
+package sqlj
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// A QueryHook that logs every query to a slog.Logger.
+// Queries are logged at debug level, failed queries at error level and
+// queries taking at least SlowThreshold at warn level.
+type SlogHook struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration // Zero disables slow query logging
+}
+
+func NewSlogHook(logger *slog.Logger, slowThreshold time.Duration) *SlogHook {
+	return &SlogHook{
+		Logger:        logger,
+		SlowThreshold: slowThreshold,
+	}
+}
+
+func (h *SlogHook) Before(ctx context.Context, event QueryEvent) {}
+
+func (h *SlogHook) After(ctx context.Context, event QueryEvent, err error, duration time.Duration) {
+	attrs := []slog.Attr{
+		slog.String("operation", string(event.Operation)),
+		slog.String("sql", event.SQL),
+		slog.Duration("duration", duration),
+	}
+
+	if event.Table != "" {
+		attrs = append(attrs, slog.String("table", event.Table))
+	}
+
+	switch {
+	case err != nil:
+		attrs = append(attrs, slog.String("error", err.Error()))
+		h.Logger.LogAttrs(ctx, slog.LevelError, "sqlj query failed", attrs...)
+	case h.SlowThreshold > 0 && duration >= h.SlowThreshold:
+		h.Logger.LogAttrs(ctx, slog.LevelWarn, "sqlj slow query", attrs...)
+	default:
+		h.Logger.LogAttrs(ctx, slog.LevelDebug, "sqlj query", attrs...)
+	}
+}
@@ -0,0 +1,193 @@
+package sqlj
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Controls whether soft deleted rows are included in the results of a QueryDB.
+type deletedScope int
+
+const (
+	excludeDeleted deletedScope = iota
+	includeDeleted
+	onlyDeleted
+)
+
+// Includes soft deleted rows in the results.
+func (q QueryDB) WithDeleted() QueryDB {
+	q.deleted = includeDeleted
+
+	return q
+}
+
+// Only returns soft deleted rows.
+func (q QueryDB) OnlyDeleted() QueryDB {
+	q.deleted = onlyDeleted
+
+	return q
+}
+
+// Returns the soft delete column for the table.
+// This comes from the softdelete tag option on t where it has one, otherwise from the model registered for the table.
+// t may be nil when the struct isn't known, e.g. for .Delete and .Count.
+func (jdb *DB) softDeleteColumn(table string, t reflect.Type) string {
+	if t != nil && t.Kind() == reflect.Struct {
+		if column := getStructMeta(t).softDelete; column != "" {
+			return column
+		}
+	}
+
+	if model, ok := jdb.models.model(table); ok {
+		return getStructMeta(model).softDelete
+	}
+
+	return ""
+}
+
+// Returns an error when t has a soft delete column that the model registered for the table doesn't.
+// Otherwise .Delete, which is only given the table, would permanently delete rows that t soft deletes.
+// Queries with no table, such as .GetRow, aren't checked.
+func (jdb *DB) checkSoftDelete(table string, t reflect.Type) error {
+	if table == "" || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	column := getStructMeta(t).softDelete
+	if column == "" {
+		return nil
+	}
+
+	if model, ok := jdb.models.model(table); ok && getStructMeta(model).softDelete == column {
+		return nil
+	}
+
+	return fmt.Errorf("%s has a softdelete field so a model with it must be registered for the %s table, see .Register", t.Name(), table)
+}
+
+// Returns the query with the where clauses of the DB scopes and the soft delete condition for the table added.
+// The existing clauses are nested so any OR conditions still only match rows in scope.
+func (q QueryDB) scoped(t reflect.Type) QueryDB {
+	if t == nil {
+		t = q.model
+	}
+
+	extra, extraValues := q.DB.scopeWhere(q.From)
+
+	if column := q.DB.softDeleteColumn(q.From, t); column != "" && q.deleted != includeDeleted {
+		expr := SimpleExpr{column + " IS NULL"}
+		if q.deleted == onlyDeleted {
+			expr = SimpleExpr{column + " IS NOT NULL"}
+		}
+
+		extra = append(extra, WhereClause{AND_TYPE, expr})
+	}
+
+	if len(extra) == 0 {
+		return q
+	}
+
+	where := []WhereClause{}
+	if len(q.WhereClauses) > 0 {
+		where = append(where, WhereClause{AND_TYPE, NestedExpr{q.WhereClauses}})
+	}
+
+	q.WhereClauses = append(where, extra...)
+	q.WhereValues = append(append([]any{}, q.WhereValues...), extraValues...)
+
+	return q
+}
+
+// Returns the where clauses for a row by ID, excluding soft deleted rows.
+func (jdb *DB) idWhere(table string, t reflect.Type) []WhereClause {
+	where := []WhereClause{
+		{AND_TYPE, SimpleExpr{columnEq(jdb.getIDName())}},
+	}
+
+	if column := jdb.softDeleteColumn(table, t); column != "" {
+		where = append(where, WhereClause{AND_TYPE, SimpleExpr{column + " IS NULL"}})
+	}
+
+	return where
+}
+
+func (jdb *DB) delete(table string, id any, t reflect.Type) error {
+	if t != nil {
+		if err := jdb.checkSoftDelete(table, t); err != nil {
+			return err
+		}
+	}
+
+	sql, values := jdb.buildDelete(table, id, jdb.softDeleteColumn(table, t))
+
+	// TODO: It would be prudent to check RowsAffected() on the result.
+	// I need to look into how this is supports with different DB drivers.
+	_, err := jdb.exec(QueryEvent{DeleteOperation, table, sql, values})
+
+	return err
+}
+
+// Builds a delete by ID, which sets the soft delete column to the current time when there is one.
+func (jdb *DB) buildDelete(table string, id any, softDelete string) (string, []any) {
+	scopeWhere, scopeValues := jdb.scopeWhere(table)
+
+	if softDelete == "" {
+		sql := buildDeleteSQL(deleteParams{
+			From: table,
+			Where: append([]WhereClause{
+				{AND_TYPE, SimpleExpr{columnEq(jdb.getIDName())}},
+			}, scopeWhere...),
+		})
+
+		return sql, append([]any{id}, scopeValues...)
+	}
+
+	deletedAt := jdb.now()
+
+	sql := buildUpdateSQL(updateParams{
+		From:     table,
+		Fields:   []field{basicField{Name: softDelete, Value: deletedAt}},
+		IDColumn: jdb.getIDName(),
+		Where:    scopeWhere,
+	})
+
+	return sql, append([]any{deletedAt, id}, scopeValues...)
+}
+
+// Permanently deletes a row in the given table by ID, even when the table has a soft delete column.
+func (jdb *DB) ForceDelete(table string, id any) error {
+	sql, values := jdb.buildDelete(table, id, "")
+
+	_, err := jdb.exec(QueryEvent{DeleteOperation, table, sql, values})
+
+	return err
+}
+
+// Restores a soft deleted row in the given table by ID.
+// The restored row is returned and marshalled into v.
+// v must be a pointer to a struct.
+func (jdb *DB) Restore(table string, id any, v any) error {
+	if err := checkValueType(v); err != nil {
+		return err
+	}
+
+	t := reflect.TypeOf(v).Elem()
+
+	column := jdb.softDeleteColumn(table, t)
+	if column == "" {
+		return errors.New("Restore requires a field with the softdelete tag option")
+	}
+
+	scopeWhere, scopeValues := jdb.scopeWhere(table)
+
+	sql := buildUpdateSQL(updateParams{
+		From:      table,
+		Fields:    []field{literalField{Name: column, Value: "NULL"}},
+		IDColumn:  jdb.getIDName(),
+		Where:     scopeWhere,
+		Returning: structColumns(t),
+	})
+
+	return jdb.getRow(QueryEvent{UpdateOperation, table, sql, append([]any{id}, scopeValues...)}, v)
+}
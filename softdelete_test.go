@@ -0,0 +1,131 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type Note struct {
+	ID        uint       `db:"id"`
+	Body      string     `db:"body"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+type NoteSummary struct {
+	ID uint `db:"id"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE note (id integer primary key, body text, deleted_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	jdb := NewDB(db)
+	jdb.Clock = func() time.Time { return now }
+
+	if query, _, _ := jdb.BuildDelete("note", 1); query != "DELETE FROM note WHERE id = $1" {
+		t.Fatalf("Expected a hard delete before the model is registered: %s\n", query)
+	}
+
+	// .Delete would hard delete the rows so the model has to be registered before it is used.
+	if err := jdb.Insert("note", &Note{Body: "first"}); err == nil {
+		t.Fatal("Expected an error using a softdelete struct that isn't registered")
+	}
+
+	if err := jdb.DeleteValue("note", 1, &Note{}); err == nil {
+		t.Fatal("Expected an error deleting with a softdelete struct that isn't registered")
+	}
+
+	// The destination is still validated before the soft delete check.
+	for _, dest := range []any{&Note{}, nil} {
+		if err := jdb.SelectAll("SELECT * FROM note", dest); err == nil || err.Error() != "dest must be a pointer to a slice of structs" {
+			t.Fatalf("Expected an error selecting into %T, got: %v\n", dest, err)
+		}
+	}
+
+	if err := jdb.Select("note", &Note{}); err == nil {
+		t.Fatal("Expected an error selecting into a struct")
+	}
+
+	if err := jdb.Register("note", &Note{}); err != nil {
+		t.Fatalf("Failed to register note: %s\n", err.Error())
+	}
+
+	for _, body := range []string{"first", "second", "third"} {
+		if err := jdb.Insert("note", &Note{Body: body}); err != nil {
+			t.Fatalf("Failed to insert note: %s\n", err.Error())
+		}
+	}
+
+	query, values, _ := jdb.BuildDelete("note", 1)
+
+	if query != "UPDATE note SET deleted_at = $1 WHERE id = $2" || len(values) != 2 || values[0] != now {
+		t.Fatalf("Unexpected soft delete SQL: %s %v\n", query, values)
+	}
+
+	if err := jdb.Delete("note", 1); err != nil {
+		t.Fatalf("Failed to delete note: %s\n", err.Error())
+	}
+
+	var note Note
+
+	if err := jdb.Get("note", 1, &note); err != sql.ErrNoRows {
+		t.Fatalf("Expected the deleted note to be excluded from .Get, got: %v\n", err)
+	}
+
+	var notes []Note
+
+	if err := jdb.Select("note", &notes); err != nil || len(notes) != 2 {
+		t.Fatalf("Expected the deleted note to be excluded from .Select: %v %+v\n", err, notes)
+	}
+
+	// The OR condition must not match deleted rows.
+	notes = nil
+
+	if err := jdb.From("note").Where("body = ?", "first").OrWhere("body = ?", "second").All(&notes); err != nil || len(notes) != 1 {
+		t.Fatalf("Expected the deleted note to be excluded from .All: %v %+v\n", err, notes)
+	}
+
+	var summaries []NoteSummary
+
+	if err := jdb.From("note").All(&summaries); err != nil || len(summaries) != 2 {
+		t.Fatalf("Expected the registered model to scope other structs: %v %+v\n", err, summaries)
+	}
+
+	if count, err := jdb.From("note").Count(); err != nil || count != 2 {
+		t.Fatalf("Expected a count of 2, got: %d\n", count)
+	}
+
+	if count, _ := jdb.From("note").WithDeleted().Count(); count != 3 {
+		t.Fatalf("Expected a count of 3 with deleted notes, got: %d\n", count)
+	}
+
+	if err := jdb.From("note").OnlyDeleted().One(&note); err != nil || note.ID != 1 || !note.DeletedAt.Equal(now) {
+		t.Fatalf("Expected only the deleted note: %v %+v\n", err, note)
+	}
+
+	if err := jdb.Restore("note", 1, &note); err != nil {
+		t.Fatalf("Failed to restore note: %s\n", err.Error())
+	}
+
+	if note.DeletedAt != nil {
+		t.Fatalf("Expected the restored note to be returned: %+v\n", note)
+	}
+
+	if err := jdb.ForceDelete("note", 1); err != nil {
+		t.Fatalf("Failed to force delete note: %s\n", err.Error())
+	}
+
+	if count, _ := jdb.From("note").WithDeleted().Count(); count != 2 {
+		t.Fatalf("Expected the note to be removed, got a count of: %d\n", count)
+	}
+}
@@ -1,14 +1,31 @@
 package sqlj
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
 )
 
 type DB struct {
-	DB           DBLike
-	IDColumn     string
-	SkipOnInsert []string // Allows you specify db field names to skip on insert
+	DB             DBLike
+	IDColumn       string
+	SkipOnInsert   []string // Columns to skip on insert and update, unless the field has write tag options
+	Dialect        Dialect  // Set automatically by Open, otherwise optional
+	Hook           QueryHook
+	Tracer         Tracer
+	Metrics        Metrics
+	Statements     *StatementCache          // Optional, see NewStatementCache
+	Clock          func() time.Time         // The time used by the autoCreateTime and autoUpdateTime tag options, defaults to time.Now
+	NamingStrategy func(name string) string // Maps a struct name to a table, see .TableFor. Defaults to SnakeCasePlural
+
+	ctx    context.Context
+	models *modelRegistry // See .Register
+	scopes []scope        // See .WithScope
 }
 
 // Represents a DB-like interface. This only specifies the methods used by sqlj.
@@ -23,9 +40,87 @@ func NewDB(db DBLike) DB {
 	return DB{
 		DB:           db,
 		SkipOnInsert: []string{"id"},
+		models:       newModelRegistry(),
 	}
 }
 
+// The struct types registered for tables, shared by copies of a DB.
+type modelRegistry struct {
+	mu     sync.RWMutex
+	tables map[string]reflect.Type   // Table -> struct type
+	types  map[reflect.Type][]string // Struct type -> tables
+}
+
+func newModelRegistry() *modelRegistry {
+	return &modelRegistry{tables: map[string]reflect.Type{}, types: map[reflect.Type][]string{}}
+}
+
+// Returns the struct type registered for the table.
+func (r *modelRegistry) model(table string) (reflect.Type, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tables[table]
+
+	return t, ok
+}
+
+// Returns the tables the struct type t is registered for in the order they were registered.
+func (r *modelRegistry) tablesFor(t reflect.Type) []string {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.types[t])
+}
+
+// Registers t for the table, replacing any type already registered for it.
+func (r *modelRegistry) register(table string, t reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if previous, ok := r.tables[table]; ok {
+		if previous == t {
+			return
+		}
+
+		r.types[previous] = slices.DeleteFunc(r.types[previous], func(other string) bool { return other == table })
+		if len(r.types[previous]) == 0 {
+			delete(r.types, previous)
+		}
+	}
+
+	r.tables[table] = t
+	r.types[t] = append(r.types[t], table)
+}
+
+// Registers the struct type of v as the model for the table.
+// This lets methods that aren't given a struct, such as .Delete and .Count, use the model's tag options.
+// A struct type can be registered for several tables, .TableFor then needs it to implement Tabler.
+// Structs with a softdelete field must be registered for the tables they are used with.
+// Registered models are shared with copies of the DB made after the first registration, e.g. by .WithContext.
+// v must be a pointer to a struct.
+func (jdb *DB) Register(table string, v any) error {
+	if err := checkValueType(v); err != nil {
+		return err
+	}
+
+	if jdb.models == nil {
+		jdb.models = newModelRegistry()
+	}
+
+	jdb.models.register(table, reflect.TypeOf(v).Elem())
+
+	return nil
+}
+
 func Open(driver string, dsn string) (*DB, error) {
 	db, err := sql.Open(driver, dsn)
 
@@ -34,11 +129,16 @@ func Open(driver string, dsn string) (*DB, error) {
 	}
 
 	jdb := NewDB(db)
+	jdb.Dialect = dialectFromDriver(driver)
 
 	return &jdb, nil
 }
 
 func (jdb *DB) Close() {
+	if jdb.Statements != nil {
+		jdb.Statements.Close()
+	}
+
 	db, ok := jdb.DB.(*sql.DB)
 
 	if ok {
@@ -53,27 +153,51 @@ func (jdb *DB) Get(table string, id any, v any) error {
 		return err
 	}
 
-	fields := extractFields(v)
-	columns := pluckNames(fields)
+	if len(jdb.scopes) > 0 {
+		return jdb.From(table).Where(columnEq(jdb.getIDName()), id).One(v)
+	}
 
-	sql := buildSelectQuery(selectParams{
-		Columns: columns,
-		From:    table,
-		Where: []WhereClause{
-			{AND_TYPE, SimpleExpr{columnEq(jdb.getIDName())}},
-		},
+	t := reflect.TypeOf(v).Elem()
+
+	sql := cachedSQL(jdb.sqlCacheKey(t, table, GetOperation), func() string {
+		return buildSelectQuery(selectParams{
+			Columns: structColumns(t),
+			From:    table,
+			Where:   jdb.idWhere(table, t),
+		})
 	})
 
-	return jdb.GetRow(sql, v, id)
+	return jdb.getRow(QueryEvent{GetOperation, table, sql, []any{id}}, v)
 }
 
 // Gets a single row using the supplied SQL and values.
 // The result will be marshalled into the v struct.
 // v must be a pointer to a struct.
 func (jdb *DB) GetRow(sql string, v any, values ...any) error {
-	row := jdb.DB.QueryRow(sql, values...)
+	return jdb.getRow(QueryEvent{Operation: GetOperation, SQL: sql, Args: values}, v)
+}
+
+func (jdb *DB) getRow(event QueryEvent, v any) error {
+	if event.Table != "" {
+		if err := checkValueType(v); err != nil {
+			return err
+		}
+
+		if err := jdb.checkSoftDelete(event.Table, reflect.TypeOf(v).Elem()); err != nil {
+			return err
+		}
+	}
+
+	err := jdb.queryRow(event, func(row *sql.Row) error {
+		return scanIntoStruct(row, v)
+	})
 
-	return scanIntoStruct(row, v)
+	// Inserts and updates also return the row, these call their own hooks.
+	if err != nil || event.Operation != GetOperation {
+		return err
+	}
+
+	return jdb.afterHook(GetOperation, v)
 }
 
 // Selects all rows from a given table.
@@ -85,28 +209,92 @@ func (jdb *DB) Select(table string, v any) error {
 		return err
 	}
 
-	fields := extractFields(structInstance)
-	columns := pluckNames(fields)
+	if len(jdb.scopes) > 0 {
+		return jdb.From(table).All(v)
+	}
+
+	t := reflect.TypeOf(structInstance).Elem()
+
+	sql := cachedSQL(jdb.sqlCacheKey(t, table, SelectOperation), func() string {
+		var where []WhereClause
+		if column := jdb.softDeleteColumn(table, t); column != "" {
+			where = []WhereClause{{AND_TYPE, SimpleExpr{column + " IS NULL"}}}
+		}
 
-	sql := buildSelectQuery(selectParams{
-		Columns: columns,
-		From:    table,
+		return buildSelectQuery(selectParams{
+			Columns: structColumns(t),
+			From:    table,
+			Where:   where,
+		})
 	})
 
-	return jdb.SelectAll(sql, v)
+	return jdb.selectAll(QueryEvent{Operation: SelectOperation, Table: table, SQL: sql}, v)
 }
 
 // Selects all rows using the supplied SQL and values.
 // The results will be marshalled into the v slice of structs.
 // v must be a pointer to a slice of structs.
 func (jdb *DB) SelectAll(sql string, v any, values ...any) error {
-	rows, err := jdb.DB.Query(sql, values...)
+	return jdb.selectAll(QueryEvent{Operation: SelectOperation, SQL: sql, Args: values}, v)
+}
+
+func (jdb *DB) selectAll(event QueryEvent, v any) error {
+	var scanned int64
+
+	if event.Table != "" {
+		structInstance, err := getSliceStructInstance(v)
+		if err != nil {
+			return err
+		}
+
+		if err := jdb.checkSoftDelete(event.Table, reflect.TypeOf(structInstance).Elem()); err != nil {
+			return err
+		}
+	}
+
+	err := jdb.query(event, func(rows *sql.Rows) (int64, error) {
+		n, err := scanRowsIntoStructs(rows, v)
+		scanned = n
+
+		return n, err
+	})
 
 	if err != nil {
 		return err
 	}
 
-	return scanRowsIntoStructs(rows, v)
+	return jdb.afterFindAll(v, scanned)
+}
+
+// Runs the lifecycle hooks for the operation around a query that writes v and returns the row into it.
+// build is called after the Before hook so any changes it makes to v are written.
+func (jdb *DB) writeRow(operation Operation, table string, v any, build func() (string, []any, error)) error {
+	if err := checkValueType(v); err != nil {
+		return err
+	}
+
+	if err := jdb.checkSoftDelete(table, reflect.TypeOf(v).Elem()); err != nil {
+		return err
+	}
+
+	if err := jdb.beforeHook(operation, v); err != nil {
+		return err
+	}
+
+	query, values, err := build()
+	if err != nil {
+		return err
+	}
+
+	if err := jdb.getRow(QueryEvent{operation, table, query, values}, v); err != nil {
+		if errors.Is(err, sql.ErrNoRows) && operation == UpdateOperation && hasVersion(v) {
+			return ErrStaleObject
+		}
+
+		return err
+	}
+
+	return jdb.afterHook(operation, v)
 }
 
 // Inserts a row into the specified `table` with the given struct.
@@ -121,32 +309,63 @@ func (jdb *DB) Insert(table string, v any) error {
 // A map of column to literal string value can be included to override any values in v.
 // v must be a pointer to a struct.
 func (jdb *DB) InsertWithFields(table string, v any, fieldMap map[string]string) error {
+	return jdb.writeRow(InsertOperation, table, v, func() (string, []any, error) {
+		return jdb.BuildInsertWithFields(table, v, fieldMap)
+	})
+}
+
+// Builds the SQL and values that .Insert would execute without executing them.
+// v must be a pointer to a struct.
+func (jdb *DB) BuildInsert(table string, v any) (string, []any, error) {
+	return jdb.BuildInsertWithFields(table, v, map[string]string{})
+}
+
+// Builds the SQL and values that .InsertWithFields would execute without executing them.
+// v must be a pointer to a struct.
+func (jdb *DB) BuildInsertWithFields(table string, v any, fieldMap map[string]string) (string, []any, error) {
+	return jdb.buildInsert(table, v, fieldMap, false)
+}
+
+// Builds an insert of v. With onConflictDoNothing a row that conflicts with an existing one isn't inserted,
+// in which case no row is returned.
+func (jdb *DB) buildInsert(table string, v any, fieldMap map[string]string, onConflictDoNothing bool) (string, []any, error) {
 	if err := checkValueType(v); err != nil {
-		return err
+		return "", nil, err
 	}
 
 	allFields := extractFields(v)
+	writtenFields, managed := applyWriteOptions(v, allFields, InsertOperation, jdb.now)
+	scopeFields := jdb.scopeFields()
 	literalFields := literalFieldsFromMap(fieldMap)
-	fields := append(allFields, literalFields...)
+	fields := append(append(writtenFields, scopeFields...), literalFields...)
 	fields = dedupeFields(fields)
 
-	filteredFields := filterFields(fields, jdb.SkipOnInsert)
+	filteredFields := filterFields(fields, jdb.skipColumns(managed))
 	returnColumns := pluckNames(allFields)
 
-	sql := buildInsertSQL(insertParams{
-		From:      table,
-		Fields:    filteredFields,
-		Returning: returnColumns,
-	})
+	build := func() string {
+		return buildInsertSQL(insertParams{
+			From:                table,
+			Fields:              filteredFields,
+			Returning:           returnColumns,
+			OnConflictDoNothing: onConflictDoNothing,
+		})
+	}
+
+	// The literal fields, scopes and value dependent tag options can differ between calls
+	// so only the plain insert is cached.
+	t := reflect.TypeOf(v).Elem()
 
-	if len(fieldMap) > 0 {
-		fmt.Printf("Fields: %v\n", fields)
-		fmt.Printf("Insert with fields SQL: %s\n", sql)
+	var sql string
+	if len(fieldMap) == 0 && len(scopeFields) == 0 && !onConflictDoNothing && !getStructMeta(t).valueDependent {
+		sql = cachedSQL(jdb.sqlCacheKey(t, table, InsertOperation), build)
+	} else {
+		sql = build()
 	}
 
 	values := pluckValues(filteredFields)
 
-	return jdb.GetRow(sql, v, values...)
+	return sql, values, nil
 }
 
 // Updates a row in the specified `table` using the given struct.
@@ -161,44 +380,177 @@ func (jdb *DB) Update(table string, id any, v any) error {
 // A map of column to literal string value can be included to override any values in v.
 // v must be a pointer to a struct.
 func (jdb *DB) UpdateWithFields(table string, id any, v any, fieldMap map[string]string) error {
+	return jdb.writeRow(UpdateOperation, table, v, func() (string, []any, error) {
+		return jdb.BuildUpdateWithFields(table, id, v, fieldMap)
+	})
+}
+
+// Builds the SQL and values that .Update would execute without executing them.
+// v must be a pointer to a struct.
+func (jdb *DB) BuildUpdate(table string, id any, v any) (string, []any, error) {
+	return jdb.BuildUpdateWithFields(table, id, v, map[string]string{})
+}
+
+// Builds the SQL and values that .UpdateWithFields would execute without executing them.
+// v must be a pointer to a struct.
+func (jdb *DB) BuildUpdateWithFields(table string, id any, v any, fieldMap map[string]string) (string, []any, error) {
 	if err := checkValueType(v); err != nil {
-		return err
+		return "", nil, err
 	}
 
+	// The literal fields, scopes and value dependent tag options can differ between calls
+	// so only the plain update is cached.
+	t := reflect.TypeOf(v).Elem()
+	cacheable := len(fieldMap) == 0 && len(jdb.scopes) == 0 && !getStructMeta(t).valueDependent
+
+	return jdb.buildUpdate(table, id, v, fieldMap, cacheable, func(fields []field) ([]field, error) {
+		return fields, nil
+	})
+}
+
+// Updates only the non-zero fields of v in the specified `table`.
+// The full updated row is returned and marshalled into v.
+// v must be a pointer to a struct.
+func (jdb *DB) Patch(table string, id any, v any) error {
+	return jdb.writeRow(UpdateOperation, table, v, func() (string, []any, error) {
+		return jdb.BuildPatch(table, id, v)
+	})
+}
+
+// Builds the SQL and values that .Patch would execute without executing them.
+// v must be a pointer to a struct.
+func (jdb *DB) BuildPatch(table string, id any, v any) (string, []any, error) {
+	if err := checkValueType(v); err != nil {
+		return "", nil, err
+	}
+
+	return jdb.buildUpdate(table, id, v, map[string]string{}, false, func(fields []field) ([]field, error) {
+		return nonZeroFields(v, fields), nil
+	})
+}
+
+// Updates only the given columns of the specified `table` from v.
+// The full updated row is returned and marshalled into v.
+// v must be a pointer to a struct.
+func (jdb *DB) UpdateColumns(table string, id any, v any, columns ...string) error {
+	return jdb.writeRow(UpdateOperation, table, v, func() (string, []any, error) {
+		return jdb.BuildUpdateColumns(table, id, v, columns...)
+	})
+}
+
+// Builds the SQL and values that .UpdateColumns would execute without executing them.
+// Each column must match the db tag of a field on v.
+// v must be a pointer to a struct.
+func (jdb *DB) BuildUpdateColumns(table string, id any, v any, columns ...string) (string, []any, error) {
+	if err := checkValueType(v); err != nil {
+		return "", nil, err
+	}
+
+	return jdb.buildUpdate(table, id, v, map[string]string{}, false, func(fields []field) ([]field, error) {
+		names := pluckNames(extractFields(v))
+
+		for _, column := range columns {
+			if !slices.Contains(names, column) {
+				return nil, fmt.Errorf("Column %q does not match a db field", column)
+			}
+		}
+
+		return slices.DeleteFunc(fields, func(f field) bool {
+			return !slices.Contains(columns, f.GetName())
+		}), nil
+	})
+}
+
+// Builds an update of v where choose selects which of the writable fields are written.
+func (jdb *DB) buildUpdate(table string, id any, v any, fieldMap map[string]string, cacheable bool, choose func([]field) ([]field, error)) (string, []any, error) {
 	allFields := extractFields(v)
+	writtenFields, managed := applyWriteOptions(v, allFields, UpdateOperation, jdb.now)
+
+	chosen, err := choose(slices.Clone(writtenFields))
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The autoUpdateTime and version columns are written however the fields are chosen.
+	chosenNames := pluckNames(chosen)
+	autoColumns := alwaysUpdatedColumns(v)
+
+	writtenFields = slices.DeleteFunc(writtenFields, func(f field) bool {
+		return !slices.Contains(chosenNames, f.GetName()) && !slices.Contains(autoColumns, f.GetName())
+	})
+
 	literalFields := literalFieldsFromMap(fieldMap)
-	fields := append(allFields, literalFields...)
+	fields := append(writtenFields, literalFields...)
 	fields = dedupeFields(fields)
 
-	filteredFields := filterFields(fields, jdb.SkipOnInsert)
+	// The row is matched by its ID so the ID column is never set, whatever its tag options.
+	filteredFields := filterFields(fields, append(jdb.skipColumns(managed), jdb.getIDName()))
 	returnColumns := pluckNames(allFields)
 
-	sql := buildUpdateSQL(updateParams{
-		From:      table,
-		Fields:    filteredFields,
-		Returning: returnColumns,
-	})
+	if len(filteredFields) == 0 {
+		return "", nil, errors.New("No columns to update")
+	}
+
+	where, whereValues := versionWhere(v)
+	scopeWhere, scopeValues := jdb.scopeWhere(table)
+	where = append(where, scopeWhere...)
+	whereValues = append(whereValues, scopeValues...)
+
+	build := func() string {
+		return buildUpdateSQL(updateParams{
+			From:      table,
+			Fields:    filteredFields,
+			IDColumn:  jdb.getIDName(),
+			Where:     where,
+			Returning: returnColumns,
+		})
+	}
+
+	var sql string
+	if cacheable {
+		sql = cachedSQL(jdb.sqlCacheKey(reflect.TypeOf(v).Elem(), table, UpdateOperation), build)
+	} else {
+		sql = build()
+	}
 
 	values := pluckValues(filteredFields)
 	values = append(values, id)
+	values = append(values, whereValues...)
 
-	return jdb.GetRow(sql, v, values...)
+	return sql, values, nil
 }
 
 // Deletes a row in the given table by ID.
+// When the model registered for the table has a soft delete column the row is kept and the column is set
+// to the current time instead, see .Register and .ForceDelete.
 func (jdb *DB) Delete(table string, id any) error {
-	sql := buildDeleteSQL(deleteParams{
-		From: table,
-		Where: []WhereClause{
-			{AND_TYPE, SimpleExpr{columnEq(jdb.getIDName())}},
-		},
-	})
+	return jdb.delete(table, id, nil)
+}
+
+// Deletes the row in the given table by ID, calling the BeforeDelete and AfterDelete hooks on v.
+// The row is soft deleted when v has a field with the softdelete tag option.
+// v must be a pointer to a struct.
+func (jdb *DB) DeleteValue(table string, id any, v any) error {
+	if err := checkValueType(v); err != nil {
+		return err
+	}
+
+	if err := jdb.beforeHook(DeleteOperation, v); err != nil {
+		return err
+	}
+
+	if err := jdb.delete(table, id, reflect.TypeOf(v).Elem()); err != nil {
+		return err
+	}
+
+	return jdb.afterHook(DeleteOperation, v)
+}
 
-	// TODO: It would be prudent to check RowsAffected() on the result.
-	// I need to look into how this is supports with different DB drivers.
-	_, err := jdb.DB.Exec(sql, id)
+// Builds the SQL and values that .Delete would execute without executing them.
+func (jdb *DB) BuildDelete(table string, id any) (string, []any, error) {
+	sql, values := jdb.buildDelete(table, id, jdb.softDeleteColumn(table, nil))
 
-	return err
+	return sql, values, nil
 }
 
 func (jdb *DB) From(table string) QueryDB {
@@ -208,6 +560,14 @@ func (jdb *DB) From(table string) QueryDB {
 	}
 }
 
+func (jdb *DB) now() time.Time {
+	if jdb.Clock == nil {
+		return time.Now()
+	}
+
+	return jdb.Clock()
+}
+
 func (jdb *DB) getIDName() string {
 	if jdb.IDColumn == "" {
 		return "id"
@@ -215,3 +575,19 @@ func (jdb *DB) getIDName() string {
 
 	return jdb.IDColumn
 }
+
+// Returns the columns an update sets from the fields of v.
+// The ID, readonly and insert columns aren't included, nor the autoUpdateTime and version columns set on every update.
+func (jdb *DB) updatableColumns(v any) []string {
+	written, managed := applyWriteOptions(v, extractFields(v), UpdateOperation, jdb.now)
+	skipped := append(jdb.skipColumns(managed), jdb.getIDName())
+
+	return pluckNames(filterFields(written, append(skipped, alwaysUpdatedColumns(v)...)))
+}
+
+// Returns the SkipOnInsert columns, less any whose writes are controlled by tag options.
+func (jdb *DB) skipColumns(managed []string) []string {
+	return slices.DeleteFunc(slices.Clone(jdb.SkipOnInsert), func(column string) bool {
+		return slices.Contains(managed, column)
+	})
+}
@@ -306,6 +306,219 @@ func TestInsertWithFields(t *testing.T) {
 	}
 }
 
+type Account struct {
+	ID        uint      `db:"id,omitempty"`
+	Name      string    `db:"name"`
+	Plan      string    `db:"plan,omitempty"`
+	Balance   int       `db:"balance,readonly"`
+	CreatedBy string    `db:"created_by,insert"`
+	CreatedAt time.Time `db:"created_at,insert,default=datetime('now')"`
+}
+
+func TestTagOptions(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE account (id integer primary key, name text, plan text default 'free', balance integer default 10, created_by text, created_at timestamp)")
+
+	jdb := NewDB(db)
+
+	account := Account{Name: "Joe", Balance: 500, CreatedBy: "admin"}
+
+	sql, values, err := jdb.BuildInsert("account", &account)
+
+	if err != nil {
+		t.Fatalf("Failed to build insert: %s\n", err.Error())
+	}
+
+	if sql != "INSERT INTO account (name, created_by, created_at) VALUES ($1, $2, datetime('now')) RETURNING id, name, plan, balance, created_by, created_at" || len(values) != 2 {
+		t.Fatalf("Unexpected insert SQL: %s\n", sql)
+	}
+
+	if err := jdb.Insert("account", &account); err != nil {
+		t.Fatalf("Failed to insert account: %s\n", err.Error())
+	}
+
+	if account.ID == 0 || account.Plan != "free" || account.Balance != 10 || account.CreatedAt.IsZero() {
+		t.Fatalf("Expected the database defaults to be returned: %+v\n", account)
+	}
+
+	createdAt := account.CreatedAt
+
+	account.Name = "Jon"
+	account.Plan = "pro"
+	account.Balance = 0
+	account.CreatedBy = "someone else"
+	account.CreatedAt = time.Time{}
+
+	sql, _, _ = jdb.BuildUpdate("account", account.ID, &account)
+
+	if sql != "UPDATE account SET name = $1, plan = $2 WHERE id = $3 RETURNING id, name, plan, balance, created_by, created_at" {
+		t.Fatalf("Unexpected update SQL: %s\n", sql)
+	}
+
+	if err := jdb.Update("account", account.ID, &account); err != nil {
+		t.Fatalf("Failed to update account: %s\n", err.Error())
+	}
+
+	if account.Name != "Jon" || account.Plan != "pro" || account.Balance != 10 || account.CreatedBy != "admin" || !account.CreatedAt.Equal(createdAt) {
+		t.Fatalf("Expected only the writable fields to be updated: %+v\n", account)
+	}
+
+	// An explicit value takes precedence over the default.
+	explicit := Account{ID: 42, Name: "Jess", CreatedAt: createdAt.Add(-time.Hour)}
+
+	if err := jdb.Insert("account", &explicit); err != nil {
+		t.Fatalf("Failed to insert account: %s\n", err.Error())
+	}
+
+	if explicit.ID != 42 || !explicit.CreatedAt.Equal(createdAt.Add(-time.Hour)) {
+		t.Fatalf("Expected the explicit values to be inserted: %+v\n", explicit)
+	}
+}
+
+func TestPatchAndUpdateColumns(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	jdb := NewDB(db)
+
+	user := User{Name: "Joe", Email: "joe@example.com", CreatedAt: time.Now()}
+
+	if err := jdb.Insert("user", &user); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	patch := User{Name: "Jon"}
+
+	sql, values, err := jdb.BuildPatch("user", user.ID, &patch)
+
+	if err != nil {
+		t.Fatalf("Failed to build patch: %s\n", err.Error())
+	}
+
+	if sql != "UPDATE user SET name = $1 WHERE id = $2 RETURNING id, name, email, created_at" || len(values) != 2 {
+		t.Fatalf("Unexpected patch SQL: %s\n", sql)
+	}
+
+	if err := jdb.Patch("user", user.ID, &patch); err != nil {
+		t.Fatalf("Failed to patch user: %s\n", err.Error())
+	}
+
+	if patch.Name != "Jon" || patch.Email != "joe@example.com" || patch.CreatedAt.IsZero() {
+		t.Fatalf("Expected the full row to be returned: %+v\n", patch)
+	}
+
+	patch.Name = "Jess"
+	patch.Email = "jess@example.com"
+
+	if err := jdb.UpdateColumns("user", user.ID, &patch, "email"); err != nil {
+		t.Fatalf("Failed to update columns: %s\n", err.Error())
+	}
+
+	if patch.Name != "Jon" || patch.Email != "jess@example.com" {
+		t.Fatalf("Expected only the email to be updated: %+v\n", patch)
+	}
+
+	if _, _, err := jdb.BuildUpdateColumns("user", user.ID, &patch, "missing"); err == nil {
+		t.Fatal("Expected an error for a column without a db field")
+	}
+
+	if err := jdb.Patch("user", user.ID, &User{}); err == nil {
+		t.Fatal("Expected an error when there is nothing to patch")
+	}
+}
+
+type Post struct {
+	ID        uint       `db:"id"`
+	Title     string     `db:"title"`
+	CreatedAt time.Time  `db:"created_at,autoCreateTime"`
+	UpdatedAt *time.Time `db:"updated_at,autoUpdateTime"`
+}
+
+func TestAutoTimestamps(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE post (id integer primary key, title text, created_at timestamp, updated_at timestamp)")
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	jdb := NewDB(db)
+	jdb.Clock = func() time.Time { return now }
+
+	post := Post{Title: "Hello"}
+
+	if err := jdb.Insert("post", &post); err != nil {
+		t.Fatalf("Failed to insert post: %s\n", err.Error())
+	}
+
+	if !post.CreatedAt.Equal(now) || post.UpdatedAt == nil || !post.UpdatedAt.Equal(now) {
+		t.Fatalf("Expected the timestamps to be set on insert: %+v\n", post)
+	}
+
+	created := now
+	now = now.Add(time.Hour)
+
+	post.Title = "Hello, world"
+	post.CreatedAt = time.Time{}
+
+	if err := jdb.Update("post", post.ID, &post); err != nil {
+		t.Fatalf("Failed to update post: %s\n", err.Error())
+	}
+
+	if !post.CreatedAt.Equal(created) || !post.UpdatedAt.Equal(now) {
+		t.Fatalf("Expected only updated_at to change on update: %+v\n", post)
+	}
+
+	now = now.Add(time.Hour)
+
+	sql, _, _ := jdb.BuildUpdateColumns("post", post.ID, &post, "title")
+
+	if sql != "UPDATE post SET title = $1, updated_at = $2 WHERE id = $3 RETURNING id, title, created_at, updated_at" {
+		t.Fatalf("Expected updated_at to be written with the chosen columns: %s\n", sql)
+	}
+
+	if err := jdb.Patch("post", post.ID, &Post{Title: "Patched"}); err != nil {
+		t.Fatalf("Failed to patch post: %s\n", err.Error())
+	}
+
+	if err := jdb.Get("post", post.ID, &post); err != nil {
+		t.Fatalf("Failed to retrieve post: %s\n", err.Error())
+	}
+
+	if post.Title != "Patched" || !post.UpdatedAt.Equal(now) {
+		t.Fatalf("Expected .Patch to set updated_at: %+v\n", post)
+	}
+
+	explicit := Post{Title: "Backdated", CreatedAt: created.Add(-time.Hour)}
+
+	if err := jdb.Insert("post", &explicit); err != nil {
+		t.Fatalf("Failed to insert post: %s\n", err.Error())
+	}
+
+	if !explicit.CreatedAt.Equal(created.Add(-time.Hour)) {
+		t.Fatalf("Expected an explicit created_at to be kept: %+v\n", explicit)
+	}
+}
+
 type Issue struct {
 	ID         uint   `db:"id"`
 	Title      string `db:"title"`
@@ -380,3 +593,53 @@ func TestNullableFields(t *testing.T) {
 		t.Fatalf("Failed to page issues: %s\n", err.Error())
 	}
 }
+
+func TestBuildSQL(t *testing.T) {
+	jdb := NewDB(nil)
+
+	user := User{Name: "Joe", Email: "joe@example.com"}
+
+	sql, values, err := jdb.BuildInsert("user", &user)
+
+	if err != nil {
+		t.Fatalf("Failed to build insert: %s\n", err.Error())
+	}
+
+	if sql != "INSERT INTO user (name, email, created_at) VALUES ($1, $2, $3) RETURNING id, name, email, created_at" {
+		t.Fatalf("Unexpected insert SQL: %s\n", sql)
+	}
+
+	if len(values) != 3 || *values[0].(*string) != "Joe" {
+		t.Fatalf("Unexpected insert values: %v\n", values)
+	}
+
+	sql, values, err = jdb.BuildUpdateWithFields("user", 4, &user, map[string]string{"email": "lower(email)"})
+
+	if err != nil {
+		t.Fatalf("Failed to build update: %s\n", err.Error())
+	}
+
+	if sql != "UPDATE user SET name = $1, email = lower(email), created_at = $2 WHERE id = $3 RETURNING id, name, email, created_at" {
+		t.Fatalf("Unexpected update SQL: %s\n", sql)
+	}
+
+	if len(values) != 3 || values[2] != 4 {
+		t.Fatalf("Unexpected update values: %v\n", values)
+	}
+
+	jdb.IDColumn = "user_id"
+
+	sql, values, err = jdb.BuildDelete("user", 4)
+
+	if err != nil {
+		t.Fatalf("Failed to build delete: %s\n", err.Error())
+	}
+
+	if sql != "DELETE FROM user WHERE user_id = $1" || len(values) != 1 {
+		t.Fatalf("Unexpected delete SQL: %s\n", sql)
+	}
+
+	if _, _, err := jdb.BuildInsert("user", user); err == nil {
+		t.Fatal("Expected an error when building an insert from a non-pointer")
+	}
+}
@@ -0,0 +1,226 @@
+package sqlj
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// A least recently used cache of prepared statements keyed by their SQL.
+// Statements are prepared on the DB the first time the SQL is used. When the
+// DB of a sqlj.DB is a transaction a cached statement is bound to it with Tx.Stmt.
+// Queries in a transaction don't prepare new statements as that would need a second
+// connection while the transaction holds one, which can deadlock a small pool.
+// Statements evicted from the cache are closed once they are no longer in use.
+// A cache can only be used by a sqlj.DB wrapping the *sql.DB it was created with, or a transaction on it.
+type StatementCache struct {
+	db       *sql.DB
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // Most recently used at the front
+	entries map[string]*list.Element
+}
+
+type cachedStatement struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// Creates a cache holding at most capacity statements prepared on db.
+func NewStatementCache(db *sql.DB, capacity int) *StatementCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &StatementCache{
+		db:       db,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// Returns the number of statements in the cache.
+func (c *StatementCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Closes every statement in the cache.
+// Statements that are in use are closed once they are released.
+func (c *StatementCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+}
+
+// Returns the cached statement for the query or nil if it hasn't been prepared.
+// release must be called once the statement is no longer in use.
+func (c *StatementCache) lookup(query string) (*sql.Stmt, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[query]
+	if !ok {
+		return nil, nil
+	}
+
+	c.order.MoveToFront(el)
+	entry := el.Value.(*cachedStatement)
+	entry.refs++
+
+	return entry.stmt, c.releaser(entry)
+}
+
+// Returns the prepared statement for the query, preparing it if necessary.
+// release must be called once the statement is no longer in use.
+func (c *StatementCache) acquire(ctx context.Context, query string) (*sql.Stmt, func(), error) {
+	if stmt, release := c.lookup(query); stmt != nil {
+		return stmt, release, nil
+	}
+
+	// The statement is prepared without holding the lock so other queries aren't blocked.
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another caller may have prepared the same query in the meantime.
+	if el, ok := c.entries[query]; ok {
+		stmt.Close()
+
+		c.order.MoveToFront(el)
+		entry := el.Value.(*cachedStatement)
+		entry.refs++
+
+		return entry.stmt, c.releaser(entry), nil
+	}
+
+	entry := &cachedStatement{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.capacity {
+		c.evict(c.order.Back())
+	}
+
+	return entry.stmt, c.releaser(entry), nil
+}
+
+func (c *StatementCache) releaser(entry *cachedStatement) func() {
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		entry.refs--
+
+		if entry.evicted && entry.refs == 0 {
+			entry.stmt.Close()
+		}
+	}
+}
+
+// Removes the element from the cache. The lock must be held.
+func (c *StatementCache) evict(el *list.Element) {
+	entry := c.order.Remove(el).(*cachedStatement)
+	delete(c.entries, entry.query)
+
+	entry.evicted = true
+
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// Returns a copy of the DB that runs queries in the transaction.
+// The hooks, tracer, metrics and statement cache are shared with the original DB.
+func (jdb *DB) WithTx(tx *sql.Tx) *DB {
+	db := *jdb
+	db.DB = tx
+
+	return &db
+}
+
+// Runs queries on a prepared statement, the query string is ignored.
+type stmtRunner struct {
+	stmt *sql.Stmt
+}
+
+func (r stmtRunner) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.stmt.ExecContext(ctx, args...)
+}
+
+func (r stmtRunner) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.stmt.QueryContext(ctx, args...)
+}
+
+func (r stmtRunner) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return r.stmt.QueryRowContext(ctx, args...)
+}
+
+// Runs queries on a DBLike that doesn't accept a context.
+type dbLikeRunner struct {
+	db DBLike
+}
+
+func (r dbLikeRunner) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.db.Exec(query, args...)
+}
+
+func (r dbLikeRunner) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.db.Query(query, args...)
+}
+
+func (r dbLikeRunner) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return r.db.QueryRow(query, args...)
+}
+
+// Returns what a query should be run on. This is a prepared statement when the DB
+// has a statement cache that applies, otherwise the DB itself.
+// release must be called once the query has finished.
+func (jdb *DB) runner(ctx context.Context, query string) (contextDBLike, func(), error) {
+	noop := func() {}
+
+	if jdb.Statements != nil {
+		switch db := jdb.DB.(type) {
+		case *sql.DB:
+			if db != jdb.Statements.db {
+				return nil, nil, errors.New("The statement cache was created for a different *sql.DB")
+			}
+
+			stmt, release, err := jdb.Statements.acquire(ctx, query)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return stmtRunner{stmt}, release, nil
+		case *sql.Tx:
+			if stmt, release := jdb.Statements.lookup(query); stmt != nil {
+				txStmt := db.StmtContext(ctx, stmt)
+
+				return stmtRunner{txStmt}, func() {
+					txStmt.Close()
+					release()
+				}, nil
+			}
+		}
+	}
+
+	if db, ok := jdb.DB.(contextDBLike); ok {
+		return db, noop, nil
+	}
+
+	return dbLikeRunner{jdb.DB}, noop, nil
+}
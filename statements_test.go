@@ -0,0 +1,146 @@
+package sqlj
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestStatementCache(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	// Each connection to :memory: is a separate database so only one is allowed.
+	db.SetMaxOpenConns(1)
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	cache := NewStatementCache(db, 2)
+
+	jdb := NewDB(db)
+	jdb.Statements = cache
+
+	userA := User{Name: "Joe", Email: "joe@example.com"}
+	userB := User{Name: "Jen", Email: "jen@example.com"}
+
+	if err := jdb.Insert("user", &userA); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	if err := jdb.Insert("user", &userB); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	if cache.Len() != 1 {
+		t.Fatalf("Expected the insert statement to be reused, got %d statements\n", cache.Len())
+	}
+
+	var found User
+	if err := jdb.Get("user", userA.ID, &found); err != nil {
+		t.Fatalf("Failed to get user: %s\n", err.Error())
+	}
+
+	if _, err := jdb.From("user").Count(); err != nil {
+		t.Fatalf("Failed to count users: %s\n", err.Error())
+	}
+
+	if cache.Len() != 2 {
+		t.Fatalf("Expected the cache to be bounded to 2 statements, got: %d\n", cache.Len())
+	}
+
+	// The insert statement was least recently used so it should have been evicted and closed.
+	if _, ok := cache.entries["SELECT count(1) FROM user"]; !ok {
+		t.Fatal("Expected the count statement to be cached")
+	}
+
+	insertSQL, _, _ := jdb.BuildInsert("user", &userA)
+	if _, ok := cache.entries[insertSQL]; ok {
+		t.Fatal("Expected the insert statement to be evicted")
+	}
+
+	tx, err := db.Begin()
+
+	if err != nil {
+		t.Fatalf("Failed to start transaction: %s\n", err.Error())
+	}
+
+	txdb := jdb.WithTx(tx)
+
+	// The count statement is cached so it is bound to the transaction,
+	// the insert isn't so it runs on the transaction without being prepared.
+	if _, err := txdb.From("user").Count(); err != nil {
+		t.Fatalf("Failed to count users in transaction: %s\n", err.Error())
+	}
+
+	userC := User{Name: "Jess", Email: "jess@example.com"}
+
+	if err := txdb.Insert("user", &userC); err != nil {
+		t.Fatalf("Failed to insert user in transaction: %s\n", err.Error())
+	}
+
+	if cache.Len() != 2 {
+		t.Fatalf("Expected no statements to be prepared in the transaction, got: %d\n", cache.Len())
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback: %s\n", err.Error())
+	}
+
+	count, err := jdb.From("user").Count()
+
+	if err != nil {
+		t.Fatalf("Failed to count users: %s\n", err.Error())
+	}
+
+	if count != 2 {
+		t.Fatalf("Expected the rolled back insert to be discarded, got %d users\n", count)
+	}
+
+	stmt, release, err := cache.acquire(context.Background(), "SELECT 1")
+
+	if err != nil {
+		t.Fatalf("Failed to prepare statement: %s\n", err.Error())
+	}
+
+	cache.Close()
+
+	// Statements in use when evicted are only closed once released.
+	var one int
+	if err := stmt.QueryRow().Scan(&one); err != nil {
+		t.Fatalf("Expected the statement to remain usable until released: %s\n", err.Error())
+	}
+
+	release()
+
+	if err := stmt.QueryRow().Scan(&one); err == nil {
+		t.Fatal("Expected the statement to be closed once released")
+	}
+
+	if cache.Len() != 0 {
+		t.Fatalf("Expected an empty cache, got: %d\n", cache.Len())
+	}
+
+	other, err := sql.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	defer other.Close()
+
+	other.SetMaxOpenConns(1)
+	other.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	otherdb := NewDB(other)
+	otherdb.Statements = NewStatementCache(db, 2)
+
+	if _, err := otherdb.From("user").Count(); err == nil || !strings.Contains(err.Error(), "different") {
+		t.Fatal("Expected an error using a statement cache created for another DB")
+	}
+}
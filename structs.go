@@ -3,104 +3,184 @@ package sqlj
 import (
 	"database/sql"
 	"errors"
+	"maps"
 	"reflect"
+	"slices"
+	"time"
 )
 
 func scanIntoStruct(row *sql.Row, dest any) error {
-	t := reflect.TypeOf(dest)
-	val := reflect.ValueOf(dest)
-
-	columns := make([]interface{}, val.Elem().NumField())
-	n := 0
-	for i := 0; i < val.Elem().NumField(); i++ {
-		dbTag := t.Elem().Field(i).Tag.Get("db")
-
-		if dbTag == "" || dbTag == "-" {
-			continue
-		}
-
-		columns[n] = val.Elem().Field(i).Addr().Interface()
-		n++
+	if model, ok := dest.(GeneratedModel); ok {
+		return row.Scan(model.SqljScanTargets()...)
 	}
 
-	return row.Scan(columns[:n]...)
+	val := reflect.ValueOf(dest).Elem()
+	meta := getStructMeta(val.Type())
+
+	return row.Scan(meta.pointers(val)...)
 }
 
-func scanRowsIntoStructs(rows *sql.Rows, dest interface{}) error {
+// Any extra targets are scanned from the columns following the struct fields on each row.
+// Returns the number of rows scanned.
+func scanRowsIntoStructs(rows *sql.Rows, dest interface{}, extra ...any) (int64, error) {
 	val := reflect.ValueOf(dest)
 	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
-		return errors.New("dest must be a pointer to a slice of structs")
+		return 0, errors.New("dest must be a pointer to a slice of structs")
 	}
 
 	structType := val.Elem().Type().Elem()
 	if structType.Kind() != reflect.Struct {
-		return errors.New("dest must be a pointer to a slice of structs")
+		return 0, errors.New("dest must be a pointer to a slice of structs")
 	}
 
-	structInstance := reflect.New(structType).Interface()
-	v := reflect.ValueOf(structInstance)
-	t := reflect.TypeOf(structInstance)
+	// Every row is scanned into the same struct before being copied into the slice
+	// so the pointers to its fields only need to be taken once.
+	instancePointer := reflect.New(structType)
+	instance := instancePointer.Elem()
 
-	for rows.Next() {
-		fieldPointers := make([]interface{}, v.Elem().NumField())
-		n := 0
-		for i := 0; i < v.Elem().NumField(); i++ {
-			dbTag := t.Elem().Field(i).Tag.Get("db")
-
-			if dbTag == "" || dbTag == "-" {
-				continue
-			}
+	var fieldPointers []any
+	if model, ok := instancePointer.Interface().(GeneratedModel); ok {
+		fieldPointers = append(model.SqljScanTargets(), extra...)
+	} else {
+		fieldPointers = append(getStructMeta(structType).pointers(instance), extra...)
+	}
 
-			fieldPointers[n] = v.Elem().Field(i).Addr().Interface()
-			n++
-		}
+	var count int64
 
-		if err := rows.Scan(fieldPointers[:n]...); err != nil {
-			return err
+	for rows.Next() {
+		if err := rows.Scan(fieldPointers...); err != nil {
+			return count, err
 		}
 
-		val.Elem().Set(reflect.Append(val.Elem(), reflect.ValueOf(structInstance).Elem()))
+		val.Elem().Set(reflect.Append(val.Elem(), instance))
+		count++
 	}
 
-	return rows.Err()
+	return count, rows.Err()
 }
 
+// The fields are sorted by name so the generated SQL is stable.
 func literalFieldsFromMap(fieldMap map[string]string) []field {
 	fields := make([]field, len(fieldMap))
 
-	idx := 0
-	for k, v := range fieldMap {
-		fields[idx] = literalField{Name: k, Value: v}
-		idx++
+	for idx, k := range slices.Sorted(maps.Keys(fieldMap)) {
+		fields[idx] = literalField{Name: k, Value: fieldMap[k]}
 	}
 
 	return fields
 }
 
 func extractFields(v any) []field {
-	t := reflect.TypeOf(v)
+	if model, ok := v.(GeneratedModel); ok {
+		columns := model.SqljColumns()
+		values := model.SqljValues()
+		fields := make([]field, len(columns))
+
+		for idx, column := range columns {
+			fields[idx] = basicField{Name: column, Value: values[idx]}
+		}
 
-	number_of_fields := t.Elem().NumField()
+		return fields
+	}
+
+	value := reflect.ValueOf(v).Elem()
+	meta := getStructMeta(value.Type())
+
+	fields := make([]field, len(meta.fields))
+
+	for idx, f := range meta.fields {
+		fields[idx] = basicField{
+			Name:  f.column,
+			Value: f.pointer(value),
+		}
+	}
+
+	return fields
+}
+
+// Applies the write tag options of v to its fields for an insert or update.
+// now supplies the time for the autoCreateTime and autoUpdateTime options.
+// Returns the fields to write and the columns controlled by tag options.
+func applyWriteOptions(v any, fields []field, operation Operation, now func() time.Time) ([]field, []string) {
 	value := reflect.ValueOf(v).Elem()
+	meta := getStructMeta(value.Type())
+
+	written := make([]field, 0, len(fields))
+	managed := []string{}
+
+	for _, f := range fields {
+		idx, ok := meta.byName[f.GetName()]
+		if !ok || !meta.fields[idx].hasWriteOptions() {
+			written = append(written, f)
+			continue
+		}
+
+		fm := meta.fields[idx]
+		managed = append(managed, fm.column)
 
-	fields := make([]field, number_of_fields)
+		if fm.hasOption(readonlyOption) {
+			continue
+		}
 
-	n := 0
-	for i := 0; i < number_of_fields; i++ {
-		dbTag := t.Elem().Field(i).Tag.Get("db")
+		if fm.hasOption(autoUpdateTimeOption) {
+			written = append(written, basicField{Name: fm.column, Value: now()})
+			continue
+		}
 
-		if dbTag == "" || dbTag == "-" {
+		if fm.hasOption(versionOption) && operation == UpdateOperation {
+			written = append(written, literalField{Name: fm.column, Value: fm.column + " + 1"})
 			continue
 		}
 
-		fields[n] = basicField{
-			Name:  dbTag,
-			Value: value.Field(i).Addr().Interface(),
+		if (fm.hasOption(insertOption) || fm.hasOption(autoCreateTimeOption)) && operation != InsertOperation {
+			continue
+		}
+
+		isZero := value.FieldByIndex(fm.index).IsZero()
+
+		if fm.hasOption(autoCreateTimeOption) && isZero {
+			written = append(written, basicField{Name: fm.column, Value: now()})
+			continue
+		}
+
+		if def, ok := fm.defaultValue(); ok && isZero && operation == InsertOperation {
+			written = append(written, literalField{Name: fm.column, Value: def})
+			continue
+		}
+
+		if fm.hasOption(omitemptyOption) && isZero {
+			continue
+		}
+
+		written = append(written, f)
+	}
+
+	return written, managed
+}
+
+// Returns the columns of v that are written on every update.
+func alwaysUpdatedColumns(v any) []string {
+	columns := []string{}
+
+	for _, f := range getStructMeta(reflect.TypeOf(v).Elem()).fields {
+		if f.hasOption(autoUpdateTimeOption) || f.hasOption(versionOption) {
+			columns = append(columns, f.column)
 		}
-		n++
 	}
 
-	return fields[:n]
+	return columns
+}
+
+// Removes the fields of v that hold their zero value.
+func nonZeroFields(v any, fields []field) []field {
+	value := reflect.ValueOf(v).Elem()
+	meta := getStructMeta(value.Type())
+
+	return slices.DeleteFunc(fields, func(f field) bool {
+		idx, ok := meta.byName[f.GetName()]
+
+		return ok && value.FieldByIndex(meta.fields[idx].index).IsZero()
+	})
 }
 
 func checkValueType(v any) error {
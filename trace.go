@@ -0,0 +1,106 @@
+package sqlj
+
+import (
+	"context"
+	"sync"
+)
+
+// The attributes set on each span.
+const (
+	OperationAttribute = "db.operation"
+	TableAttribute     = "db.sql.table"
+	StatementAttribute = "db.statement"
+	RowsAttribute      = "db.rows"
+)
+
+// Starts a span for each query executed by a DB.
+// This is intentionally small so it can be implemented by an adapter for OpenTelemetry or any other tracer.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// A single traced query.
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// A Tracer that keeps every span in memory.
+// This is useful for asserting on the queries made in tests.
+type TraceRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// A span recorded by a TraceRecorder.
+type RecordedSpan struct {
+	Name       string
+	Attributes map[string]any
+	Err        error
+	Ended      bool
+
+	recorder *TraceRecorder
+}
+
+func NewTraceRecorder() *TraceRecorder {
+	return &TraceRecorder{}
+}
+
+func (r *TraceRecorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &RecordedSpan{
+		Name:       name,
+		Attributes: map[string]any{},
+		recorder:   r,
+	}
+
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+
+	return ctx, span
+}
+
+// Returns a copy of the spans recorded so far.
+func (r *TraceRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := make([]RecordedSpan, len(r.spans))
+
+	for idx, s := range r.spans {
+		spans[idx] = *s
+		spans[idx].Attributes = make(map[string]any, len(s.Attributes))
+
+		for k, v := range s.Attributes {
+			spans[idx].Attributes[k] = v
+		}
+	}
+
+	return spans
+}
+
+// Removes the spans recorded so far.
+func (r *TraceRecorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
+
+func (s *RecordedSpan) SetAttribute(key string, value any) {
+	s.recorder.mu.Lock()
+	s.Attributes[key] = value
+	s.recorder.mu.Unlock()
+}
+
+func (s *RecordedSpan) RecordError(err error) {
+	s.recorder.mu.Lock()
+	s.Err = err
+	s.recorder.mu.Unlock()
+}
+
+func (s *RecordedSpan) End() {
+	s.recorder.mu.Lock()
+	s.Ended = true
+	s.recorder.mu.Unlock()
+}
@@ -0,0 +1,71 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	recorder := NewTraceRecorder()
+
+	jdb := NewDB(db)
+	jdb.Tracer = recorder
+
+	userA := User{Name: "Joe", Email: "joe@example.com"}
+	userB := User{Name: "Jen", Email: "jen@example.com"}
+
+	if err := jdb.Insert("user", &userA); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	if err := jdb.Insert("user", &userB); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	var users []User
+	if err := jdb.From("user").All(&users); err != nil {
+		t.Fatalf("Failed to select users: %s\n", err.Error())
+	}
+
+	if err := jdb.Delete("user", userA.ID); err != nil {
+		t.Fatalf("Failed to delete user: %s\n", err.Error())
+	}
+
+	if err := jdb.GetRow("SELECT id, name, email, created_at FROM missing", &userA); err == nil {
+		t.Fatal("Expected an error selecting from a missing table")
+	}
+
+	spans := recorder.Spans()
+
+	if len(spans) != 5 {
+		t.Fatalf("Expected 5 spans, got: %d\n", len(spans))
+	}
+
+	selectSpan := spans[2]
+
+	if selectSpan.Name != "sqlj.select" || !selectSpan.Ended {
+		t.Fatalf("Unexpected select span: %+v\n", selectSpan)
+	}
+
+	if selectSpan.Attributes[TableAttribute] != "user" || selectSpan.Attributes[RowsAttribute] != int64(2) {
+		t.Fatalf("Unexpected select span attributes: %v\n", selectSpan.Attributes)
+	}
+
+	if spans[3].Attributes[OperationAttribute] != "delete" || spans[3].Attributes[RowsAttribute] != int64(1) {
+		t.Fatalf("Unexpected delete span attributes: %v\n", spans[3].Attributes)
+	}
+
+	if spans[4].Err == nil || spans[4].Attributes[StatementAttribute] != "SELECT id, name, email, created_at FROM missing" {
+		t.Fatalf("Expected the failed query to be recorded: %+v\n", spans[4])
+	}
+}
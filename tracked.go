@@ -0,0 +1,183 @@
+package sqlj
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+)
+
+// A single column that differs from the value originally loaded.
+type Change struct {
+	Column string
+	Old    any
+	New    any
+}
+
+// Tracked wraps a struct loaded from the database and records its original values.
+// Save only updates the columns that have changed since it was loaded or last saved.
+// Make changes to Value. The original is a deep copy so maps, slices and pointers can be changed in place.
+type Tracked[T any] struct {
+	Value T
+
+	original T
+	db       *DB
+	table    string
+	id       any
+}
+
+// Gets a single row from the given table with the given id and tracks the changes made to it.
+// T must be a struct.
+func GetTracked[T any](jdb *DB, table string, id any) (*Tracked[T], error) {
+	tracked := &Tracked[T]{db: jdb, table: table, id: id}
+
+	if err := jdb.Get(table, id, &tracked.Value); err != nil {
+		return nil, err
+	}
+
+	tracked.snapshot()
+
+	return tracked, nil
+}
+
+// Tracks the changes made to a value that has already been loaded from the row with the given id.
+// T must be a struct.
+func Track[T any](jdb *DB, table string, id any, value T) (*Tracked[T], error) {
+	if reflect.TypeOf(value) == nil || reflect.TypeOf(value).Kind() != reflect.Struct {
+		return nil, errors.New("Value must be a struct")
+	}
+
+	tracked := &Tracked[T]{Value: value, db: jdb, table: table, id: id}
+	tracked.snapshot()
+
+	return tracked, nil
+}
+
+// Returns the columns that have changed, in the order of the struct fields.
+func (t *Tracked[T]) Changes() []Change {
+	current := reflect.ValueOf(&t.Value).Elem()
+	original := reflect.ValueOf(&t.original).Elem()
+	meta := getStructMeta(current.Type())
+
+	changes := []Change{}
+
+	for _, f := range meta.fields {
+		before := original.FieldByIndex(f.index).Interface()
+		after := current.FieldByIndex(f.index).Interface()
+
+		if !reflect.DeepEqual(before, after) {
+			changes = append(changes, Change{Column: f.column, Old: before, New: after})
+		}
+	}
+
+	return changes
+}
+
+// Reports whether any column has changed.
+func (t *Tracked[T]) Changed() bool {
+	return len(t.Changes()) > 0
+}
+
+// Updates the changed columns. No query is made when none of them are written on update,
+// e.g. when only readonly or insert columns have changed.
+// The updated row is marshalled into Value and becomes the new original.
+func (t *Tracked[T]) Save() error {
+	updatable := t.db.updatableColumns(&t.Value)
+	columns := []string{}
+
+	for _, c := range t.Changes() {
+		if slices.Contains(updatable, c.Column) {
+			columns = append(columns, c.Column)
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if err := t.db.UpdateColumns(t.table, t.id, &t.Value, columns...); err != nil {
+		return err
+	}
+
+	t.snapshot()
+
+	return nil
+}
+
+// Discards the changes made to Value.
+func (t *Tracked[T]) Reset() {
+	t.Value = deepCopy(reflect.ValueOf(t.original)).Interface().(T)
+}
+
+// Records a deep copy of Value as the original.
+func (t *Tracked[T]) snapshot() {
+	t.original = deepCopy(reflect.ValueOf(t.Value)).Interface().(T)
+}
+
+// Returns a copy of v that doesn't share any maps, slices or pointers with it.
+// Unexported struct fields are copied as they are.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for idx := range v.Len() {
+			c.Index(idx).Set(deepCopy(v.Index(idx)))
+		}
+
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for idx := range v.Len() {
+			c.Index(idx).Set(deepCopy(v.Index(idx)))
+		}
+
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+
+		for idx := range v.NumField() {
+			if c.Field(idx).CanSet() {
+				c.Field(idx).Set(deepCopy(v.Field(idx)))
+			}
+		}
+
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,200 @@
+package sqlj
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestTracked(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE user (id integer primary key, name text, email text, created_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	hook := &recordingHook{}
+
+	jdb := NewDB(db)
+
+	user := User{Name: "Joe", Email: "joe@example.com"}
+
+	if err := jdb.Insert("user", &user); err != nil {
+		t.Fatalf("Failed to insert user: %s\n", err.Error())
+	}
+
+	jdb.Hook = hook
+
+	tracked, err := GetTracked[User](&jdb, "user", user.ID)
+
+	if err != nil {
+		t.Fatalf("Failed to get tracked user: %s\n", err.Error())
+	}
+
+	if err := tracked.Save(); err != nil {
+		t.Fatalf("Failed to save user: %s\n", err.Error())
+	}
+
+	if len(hook.before) != 1 {
+		t.Fatalf("Expected no query when nothing has changed, got: %d\n", len(hook.before)-1)
+	}
+
+	tracked.Value.Email = "joe@example.org"
+
+	changes := tracked.Changes()
+
+	if len(changes) != 1 || changes[0].Column != "email" || changes[0].Old != "joe@example.com" || changes[0].New != "joe@example.org" {
+		t.Fatalf("Unexpected changes: %+v\n", changes)
+	}
+
+	if err := tracked.Save(); err != nil {
+		t.Fatalf("Failed to save user: %s\n", err.Error())
+	}
+
+	update := hook.before[1]
+
+	if update.SQL != "UPDATE user SET email = $1 WHERE id = $2 RETURNING id, name, email, created_at" {
+		t.Fatalf("Expected only the changed column to be updated, got: %s\n", update.SQL)
+	}
+
+	if tracked.Changed() {
+		t.Fatalf("Expected no changes after saving: %+v\n", tracked.Changes())
+	}
+
+	tracked.Value.Name = "Jon"
+	tracked.Reset()
+
+	if tracked.Value.Name != "Joe" || tracked.Changed() {
+		t.Fatalf("Expected the changes to be discarded: %+v\n", tracked.Value)
+	}
+
+	if _, err := Track(&jdb, "user", user.ID, 5); err == nil {
+		t.Fatal("Expected an error tracking a non-struct")
+	}
+}
+
+func TestTrackedInPlaceChanges(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE profile (id integer primary key, settings text not null, extra text, labels text)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	jdb := NewDB(db)
+
+	profile := Profile{
+		Settings: Settings{Theme: "dark", Alerts: []string{"email"}},
+		Labels:   map[string]string{"team": "core"},
+	}
+
+	if err := jdb.Insert("profile", &profile); err != nil {
+		t.Fatalf("Failed to insert profile: %s\n", err.Error())
+	}
+
+	tracked, err := GetTracked[Profile](&jdb, "profile", profile.ID)
+
+	if err != nil {
+		t.Fatalf("Failed to get tracked profile: %s\n", err.Error())
+	}
+
+	tracked.Value.Labels["team"] = "web"
+	tracked.Value.Settings.Alerts[0] = "sms"
+
+	changes := tracked.Changes()
+
+	if len(changes) != 2 || changes[0].Column != "settings" || changes[1].Column != "labels" {
+		t.Fatalf("Expected the map and slice changes to be detected: %+v\n", changes)
+	}
+
+	if err := tracked.Save(); err != nil {
+		t.Fatalf("Failed to save profile: %s\n", err.Error())
+	}
+
+	var found Profile
+
+	if err := jdb.Get("profile", profile.ID, &found); err != nil {
+		t.Fatalf("Failed to retrieve profile: %s\n", err.Error())
+	}
+
+	if found.Labels["team"] != "web" || found.Settings.Alerts[0] != "sms" {
+		t.Fatalf("Expected the changes to be saved: %+v\n", found)
+	}
+
+	tracked.Value.Labels["team"] = "ops"
+	tracked.Reset()
+
+	if tracked.Value.Labels["team"] != "web" || tracked.Changed() {
+		t.Fatalf("Expected the in place change to be discarded: %+v\n", tracked.Value)
+	}
+}
+
+func TestTrackedUnwritableChanges(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	defer db.Close()
+
+	db.Exec("CREATE TABLE post (id integer primary key, title text, created_at timestamp, updated_at timestamp)")
+
+	if err != nil {
+		t.Fatalf("Failed to open db: %s\n", err.Error())
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	hook := &recordingHook{}
+
+	jdb := NewDB(db)
+	jdb.Clock = func() time.Time { return now }
+
+	post := Post{Title: "Hello"}
+
+	if err := jdb.Insert("post", &post); err != nil {
+		t.Fatalf("Failed to insert post: %s\n", err.Error())
+	}
+
+	jdb.Hook = hook
+	now = now.Add(time.Hour)
+
+	tracked, err := GetTracked[Post](&jdb, "post", post.ID)
+
+	if err != nil {
+		t.Fatalf("Failed to get tracked post: %s\n", err.Error())
+	}
+
+	// created_at is only written on insert and updated_at on every update.
+	tracked.Value.CreatedAt = now
+	tracked.Value.UpdatedAt = &now
+
+	if len(tracked.Changes()) != 2 {
+		t.Fatalf("Expected both columns to be reported as changed: %+v\n", tracked.Changes())
+	}
+
+	if err := tracked.Save(); err != nil {
+		t.Fatalf("Failed to save post: %s\n", err.Error())
+	}
+
+	if len(hook.before) != 1 {
+		t.Fatalf("Expected no query when only unwritten columns have changed, got: %d\n", len(hook.before)-1)
+	}
+
+	tracked.Value.Title = "Hello, world"
+
+	if err := tracked.Save(); err != nil {
+		t.Fatalf("Failed to save post: %s\n", err.Error())
+	}
+
+	if update := hook.before[1]; update.SQL != "UPDATE post SET title = $1, updated_at = $2 WHERE id = $3 RETURNING id, title, created_at, updated_at" {
+		t.Fatalf("Expected only the title and updated_at to be updated, got: %s\n", update.SQL)
+	}
+
+	if !tracked.Value.CreatedAt.Equal(post.CreatedAt) {
+		t.Fatalf("Expected created_at not to be updated: %+v\n", tracked.Value)
+	}
+}